@@ -0,0 +1,123 @@
+package tileconv
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	// AppleHiResWidth and AppleHiResHeight are the size of an Apple II
+	// hi-res screen, in pixels.
+	AppleHiResWidth  = 280
+	AppleHiResHeight = 192
+
+	// AppleHiResSize is the size of an Apple II hi-res screen in memory,
+	// including the unused bytes ("screen holes") between the rows.
+	AppleHiResSize = 0x2000
+
+	appleHiResRowBytes = AppleHiResWidth / 7
+	appleHiResPalette  = 0x80
+)
+
+// AppleHiResPalette is a palette for Apple II hi-res images, where bit 0
+// of the color index is the pixel, and bit 1 is the palette bit of the
+// byte that holds the pixel.
+//
+// Since the color actually shown for a pixel depends on its neighbours
+// and the column it is in, this palette shows the pixels in monochrome,
+// with the same colors for both palette bits.
+var AppleHiResPalette = color.Palette{
+	color.RGBA{0x00, 0x00, 0x00, 0xFF}, // off
+	color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, // on
+	color.RGBA{0x00, 0x00, 0x00, 0xFF}, // off, palette bit set
+	color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, // on, palette bit set
+}
+
+// appleHiResRowOffset returns the offset of the given pixel row in the
+// screen memory, which is split into eight blocks that each hold every
+// eighth row, each of which is split into groups of three 40-byte rows
+// (from the top, middle and bottom third of the screen) padded to 128.
+func appleHiResRowOffset(y int) int {
+	return (y&7)<<10 | (y>>3&7)<<7 | (y>>6)*appleHiResRowBytes
+}
+
+// EncodeAppleHiRes encodes an image into Apple II hi-res screen memory.
+//
+// The image is read starting at its top-left corner, and must use the
+// AppleHiResPalette color indexes (0-3). Each byte holds 7 pixels (the
+// leftmost in bit 0) and a palette bit (bit 7), so each group of 7
+// pixels must use the same palette bit. If any groups do not, a
+// *ColorClashError is returned that describes all of those groups.
+//
+// The screen holes are left as zero.
+func EncodeAppleHiRes(src image.PalettedImage) ([]byte, error) {
+	data := make([]byte, AppleHiResSize)
+
+	var clashes []ColorClash
+	min := src.Bounds().Min
+	for y := 0; y < AppleHiResHeight; y++ {
+		row := data[appleHiResRowOffset(y):]
+		for bx := 0; bx < appleHiResRowBytes; bx++ {
+			group := image.Rect(0, 0, 7, 1).Add(min.Add(image.Pt(bx*7, y)))
+			colors := cellColors(src, group, 1)
+			if max := colors[len(colors)-1]; max > 3 {
+				return nil, fmt.Errorf(
+					"invalid Apple II hi-res color %v in group at %v",
+					max, group.Min,
+				)
+			}
+			if colors[0]&2 != colors[len(colors)-1]&2 {
+				clashes = append(clashes, ColorClash{
+					Cell: group, Colors: colors, Max: 2,
+					Reason: "mixes palette bits",
+				})
+				continue
+			}
+
+			var b byte
+			if colors[0]&2 != 0 {
+				b = appleHiResPalette
+			}
+			for px := 0; px < 7; px++ {
+				if src.ColorIndexAt(group.Min.X+px, y)&1 != 0 {
+					b |= 1 << px
+				}
+			}
+			row[bx] = b
+		}
+	}
+
+	if len(clashes) > 0 {
+		return nil, &ColorClashError{Clashes: clashes}
+	}
+
+	return data, nil
+}
+
+// DecodeAppleHiRes decodes Apple II hi-res screen memory into a new
+// image that uses the AppleHiResPalette. The data can leave out the
+// final screen hole, as is common for files saved from memory.
+func DecodeAppleHiRes(data []byte) (*image.Paletted, error) {
+	if len(data) != AppleHiResSize && len(data) != AppleHiResSize-8 {
+		return nil, errors.New("invalid Apple II hi-res screen size")
+	}
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, AppleHiResWidth, AppleHiResHeight),
+		append(AppleHiResPalette[:0:0], AppleHiResPalette...),
+	)
+	for y := 0; y < AppleHiResHeight; y++ {
+		row := data[appleHiResRowOffset(y):]
+		for bx := 0; bx < appleHiResRowBytes; bx++ {
+			b := row[bx]
+			pal := uint8(b>>6) & 2
+			for px := 0; px < 7; px++ {
+				img.SetColorIndex(bx*7+px, y, pal|uint8(b>>px)&1)
+			}
+		}
+	}
+
+	return img, nil
+}
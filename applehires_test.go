@@ -0,0 +1,108 @@
+package tileconv_test
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func newAppleHiResTestImage() *image.Paletted {
+	return image.NewPaletted(
+		image.Rect(0, 0, 280, 192), tileconv.AppleHiResPalette,
+	)
+}
+
+func TestEncodeAppleHiRes_Layout(t *testing.T) {
+	src := newAppleHiResTestImage()
+	// Row 0: leftmost pixel on, and the next group with the palette bit.
+	src.SetColorIndex(0, 0, 1)
+	for x := 7; x < 14; x++ {
+		src.SetColorIndex(x, 0, 2)
+	}
+	src.SetColorIndex(13, 0, 3)
+	// Row 1 is in the second block of rows.
+	src.SetColorIndex(1, 1, 1)
+	// Row 8 is the second row of the first block.
+	src.SetColorIndex(279, 8, 1)
+	// Row 64 is in the middle third of the screen.
+	src.SetColorIndex(2, 64, 1)
+
+	data, err := tileconv.EncodeAppleHiRes(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := make([]byte, tileconv.AppleHiResSize)
+	want[0x0000] = 0b00000001
+	want[0x0001] = 0b11000000
+	want[0x0400] = 0b00000010
+	want[0x0080+39] = 0b01000000
+	want[0x0028] = 0b00000100
+	verify(t, "bad screen data", data, want)
+}
+
+func TestAppleHiResRoundTrip(t *testing.T) {
+	src := newAppleHiResTestImage()
+	for y := 0; y < 192; y++ {
+		for x := 0; x < 280; x++ {
+			c := uint8(x*y+x/3) & 1
+			if (x/7+y)%3 == 0 {
+				c |= 2
+			}
+			src.SetColorIndex(x, y, c)
+		}
+	}
+
+	data, err := tileconv.EncodeAppleHiRes(src)
+	if err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	got, err := tileconv.DecodeAppleHiRes(data)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	verify(t, "bad decoded image", got, src)
+
+	// The last screen hole is often left out of saved files.
+	got, err = tileconv.DecodeAppleHiRes(data[:len(data)-8])
+	if err != nil {
+		t.Fatalf("unexpected decode error for short data: %v", err)
+	}
+	verify(t, "bad decoded image for short data", got, src)
+
+	if _, err := tileconv.DecodeAppleHiRes(data[1:]); err == nil {
+		t.Errorf("missing error for invalid size")
+	}
+}
+
+func TestEncodeAppleHiRes_Clash(t *testing.T) {
+	src := newAppleHiResTestImage()
+	src.SetColorIndex(3, 5, 3)
+	src.SetColorIndex(20, 100, 2)
+
+	_, err := tileconv.EncodeAppleHiRes(src)
+
+	var ce *tileconv.ColorClashError
+	if !errors.As(err, &ce) {
+		t.Fatalf("wrong error: want *ColorClashError, got %#v", err)
+	}
+	reason := "mixes palette bits"
+	verify(t, "bad clashes", ce.Clashes, []tileconv.ColorClash{
+		{
+			Cell: image.Rect(0, 5, 7, 6), Colors: []uint8{0, 3},
+			Max: 2, Reason: reason,
+		},
+		{
+			Cell: image.Rect(14, 100, 21, 101), Colors: []uint8{0, 2},
+			Max: 2, Reason: reason,
+		},
+	})
+
+	src.SetColorIndex(0, 0, 4)
+	if _, err := tileconv.EncodeAppleHiRes(src); err == nil {
+		t.Errorf("missing error for invalid color")
+	}
+}
@@ -0,0 +1,363 @@
+package tileconv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BitLayout is a Codec that encodes each tile according to a
+// declarative description of where each bit of the tile is stored,
+// which lets it handle many formats without writing a new codec.
+//
+// The bits of a tile are given by three axes: the pixel column (x),
+// the pixel row (y) and the bit plane (p). The Order lists those axes
+// from the outermost to the innermost, and the bits are stored in the
+// order given by looping over the axes in that order, filling each
+// word from its highest bit (or lowest, with LSBFirst) before moving
+// on to the next word.
+//
+// The planes can also be split into groups of PlaneGroup planes each,
+// with the group (g) as a separate axis, in which case the p axis only
+// loops over the planes of a single group. If the bit depth is not a
+// multiple of the group size, the last group is padded with zeroed
+// planes.
+//
+// An axis can be prefixed with "-" to loop over it in reverse, from the
+// highest to the lowest value.
+//
+// The existing codecs can be expressed (for any bit depth) as:
+//
+//   - Packed: order y,x,-p
+//   - RowPlanar: order y,p,x
+//   - TilePlanar: order p,y,x
+//   - TileRowPairPlanar: order g,y,p,x with PlaneGroup 2
+//   - PackedWord: order y,x,-p with WordSize 2 (Neo Geo Pocket), or
+//     y,x,p with WordSize 2 and LSBFirst (Virtual Boy)
+type BitLayout struct {
+	BitDepth BitDepth
+
+	// Width and Height are the size of each tile, in pixels (default 8).
+	Width, Height int
+
+	// Order is a comma-separated list of the axes, from the outermost to
+	// the innermost, e.g. "y,x,-p". If empty, it is the same as Packed.
+	Order string
+
+	// PlaneGroup is the number of planes in each group, for the g axis.
+	// It must be set if and only if the Order includes the g axis.
+	PlaneGroup int
+
+	// WordSize is the number of bytes in each word (default 1). A tile
+	// is always padded to a whole number of words.
+	WordSize int
+
+	// BigEndian makes the words be stored with the most significant
+	// byte first; otherwise, the least significant byte is first.
+	BigEndian bool
+
+	// LSBFirst makes the bits fill each word starting from its lowest
+	// bit; otherwise, they start from its highest bit.
+	LSBFirst bool
+}
+
+var _ Codec = BitLayout{}
+var _ TileSizer = BitLayout{}
+
+// layoutAxis is one of the parsed axes of a BitLayout's Order.
+type layoutAxis struct {
+	name    byte
+	reverse bool
+}
+
+// ParseBitLayout parses a textual description of a BitLayout.
+//
+// The description is a list of key=value settings, separated by spaces,
+// newlines or semicolons, where a "#" starts a comment that runs to the
+// end of the line. The settings are:
+//
+//	bpp=N               BitDepth (1-8); often given separately instead
+//	tile=WxH            Width and Height
+//	order=A,B,...       Order
+//	group=N             PlaneGroup
+//	word=N              WordSize
+//	endian=little|big   BigEndian
+//	bits=msb|lsb        LSBFirst
+//
+// For example, TileRowPairPlanar is "order=g,y,p,x group=2".
+func ParseBitLayout(s string) (BitLayout, error) {
+	var c BitLayout
+	var fields []string
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields = append(fields, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ';' || r == ' ' || r == '\t' || r == '\r'
+		})...)
+	}
+
+	for _, f := range fields {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok {
+			return c, fmt.Errorf("bit layout setting without value: %q", f)
+		}
+		var err error
+		switch key {
+		case "bpp":
+			err = c.BitDepth.UnmarshalText([]byte(value))
+		case "tile":
+			w, h, _ := strings.Cut(value, "x")
+			if c.Width, err = strconv.Atoi(w); err == nil {
+				c.Height, err = strconv.Atoi(h)
+			}
+		case "order":
+			c.Order = value
+		case "group":
+			c.PlaneGroup, err = strconv.Atoi(value)
+		case "word":
+			c.WordSize, err = strconv.Atoi(value)
+		case "endian":
+			switch value {
+			case "little", "big":
+				c.BigEndian = value == "big"
+			default:
+				err = errors.New("must be little or big")
+			}
+		case "bits":
+			switch value {
+			case "msb", "lsb":
+				c.LSBFirst = value == "lsb"
+			default:
+				err = errors.New("must be msb or lsb")
+			}
+		default:
+			return c, fmt.Errorf("unknown bit layout setting: %q", key)
+		}
+		if err != nil {
+			return c, fmt.Errorf(
+				"invalid bit layout %s %q: %w", key, value, err,
+			)
+		}
+	}
+
+	return c, c.Validate()
+}
+
+// String returns the description of the layout, in the format that is
+// accepted by ParseBitLayout, leaving out settings that are default.
+func (c BitLayout) String() string {
+	var s []string
+	if c.BitDepth != 0 {
+		s = append(s, fmt.Sprintf("bpp=%d", c.BitDepth))
+	}
+	if c.Width != 0 || c.Height != 0 {
+		w, h := c.TileSize()
+		s = append(s, fmt.Sprintf("tile=%dx%d", w, h))
+	}
+	if c.Order != "" {
+		s = append(s, "order="+c.Order)
+	}
+	if c.PlaneGroup != 0 {
+		s = append(s, fmt.Sprintf("group=%d", c.PlaneGroup))
+	}
+	if c.WordSize != 0 {
+		s = append(s, fmt.Sprintf("word=%d", c.WordSize))
+	}
+	if c.BigEndian {
+		s = append(s, "endian=big")
+	}
+	if c.LSBFirst {
+		s = append(s, "bits=lsb")
+	}
+	return strings.Join(s, " ")
+}
+
+// Validate returns an error if the layout is not valid. Encode and
+// Decode may panic if they are used with an invalid layout.
+func (c BitLayout) Validate() error {
+	if c.BitDepth > BD8 {
+		return fmt.Errorf("invalid bit depth: %v", c.BitDepth)
+	}
+	if c.Width < 0 || c.Height < 0 {
+		return errors.New("invalid bit layout tile size")
+	}
+	if c.WordSize < 0 || c.WordSize > 8 {
+		return fmt.Errorf("invalid bit layout word size: %v", c.WordSize)
+	}
+	if c.PlaneGroup < 0 || c.PlaneGroup > 8 {
+		return fmt.Errorf("invalid bit layout plane group: %v", c.PlaneGroup)
+	}
+	_, err := c.axes()
+	return err
+}
+
+// axes returns the parsed Order.
+func (c BitLayout) axes() ([]layoutAxis, error) {
+	order := c.Order
+	if order == "" {
+		order = "y,x,-p"
+	}
+
+	var axes []layoutAxis
+	seen := map[byte]bool{}
+	for _, s := range strings.Split(order, ",") {
+		a := layoutAxis{}
+		if strings.HasPrefix(s, "-") {
+			a.reverse = true
+			s = s[1:]
+		}
+		if len(s) != 1 || !strings.Contains("xypg", s) {
+			return nil, fmt.Errorf("invalid bit layout axis: %q", s)
+		}
+		a.name = s[0]
+		if seen[a.name] {
+			return nil, fmt.Errorf("repeated bit layout axis: %q", s)
+		}
+		seen[a.name] = true
+		axes = append(axes, a)
+	}
+
+	if !seen['x'] || !seen['y'] || !seen['p'] {
+		return nil, errors.New("bit layout order must include x, y and p")
+	}
+	if seen['g'] != (c.PlaneGroup > 0) {
+		return nil, errors.New(
+			"bit layout plane group must be set if and only if g is used",
+		)
+	}
+
+	return axes, nil
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c BitLayout) TileSize() (w, h int) {
+	w, h = c.Width, c.Height
+	if w <= 0 {
+		w = 8
+	}
+	if h <= 0 {
+		h = 8
+	}
+	return w, h
+}
+
+func (c BitLayout) wordSize() int {
+	if c.WordSize > 0 {
+		return c.WordSize
+	}
+	return 1
+}
+
+// groups returns the number of plane groups and the planes per group.
+func (c BitLayout) groups() (groups, planes int) {
+	if c.PlaneGroup <= 0 {
+		return 1, c.BitDepth.Planes()
+	}
+	return (c.BitDepth.Planes() + c.PlaneGroup - 1) / c.PlaneGroup,
+		c.PlaneGroup
+}
+
+// Size implements Codec, returning the size of a tile.
+func (c BitLayout) Size() int {
+	w, h := c.TileSize()
+	groups, planes := c.groups()
+	wordBits := c.wordSize() * 8
+	words := (w*h*groups*planes + wordBits - 1) / wordBits
+	return words * c.wordSize()
+}
+
+// forEachBit calls fn for each bit of a tile, in the order they are
+// stored, with the byte index and bit mask of where it is stored, and
+// the pixel and plane that it belongs to.
+func (c BitLayout) forEachBit(fn func(i int, mask byte, x, y, p int)) {
+	axes, err := c.axes()
+	if err != nil {
+		panic(err)
+	}
+
+	w, h := c.TileSize()
+	groups, planes := c.groups()
+	size := map[byte]int{'x': w, 'y': h, 'p': planes, 'g': groups}
+	for _, a := range axes {
+		if size[a.name] <= 0 {
+			return
+		}
+	}
+
+	ws := c.wordSize()
+	wordBits := ws * 8
+
+	pos := make([]int, len(axes))
+	value := func(a int) int {
+		if axes[a].reverse {
+			return size[axes[a].name] - 1 - pos[a]
+		}
+		return pos[a]
+	}
+
+	var coord [256]int
+	for s := 0; ; s++ {
+		for a := range axes {
+			coord[axes[a].name] = value(a)
+		}
+
+		bit := s % wordBits
+		if !c.LSBFirst {
+			bit = wordBits - 1 - bit
+		}
+		b := bit / 8
+		if c.BigEndian {
+			b = ws - 1 - b
+		}
+		fn(
+			s/wordBits*ws+b, 1<<(bit%8),
+			coord['x'], coord['y'], coord['g']*planes+coord['p'],
+		)
+
+		// Step to the next bit, innermost axis first.
+		a := len(axes) - 1
+		for ; a >= 0; a-- {
+			pos[a]++
+			if pos[a] < size[axes[a].name] {
+				break
+			}
+			pos[a] = 0
+		}
+		if a < 0 {
+			return
+		}
+	}
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c BitLayout) Encode(src SourceImage, x, y int, dst []byte) {
+	size := c.Size()
+	for i := 0; i < size; i++ {
+		dst[i] = 0
+	}
+	bpp := c.BitDepth.Planes()
+	c.forEachBit(func(i int, mask byte, ix, iy, p int) {
+		if p < bpp && src.ColorIndexAt(x+ix, y+iy)&(1<<p) != 0 {
+			dst[i] |= mask
+		}
+	})
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c BitLayout) Decode(src []byte, dst DestImage, x, y int) {
+	w, h := c.TileSize()
+	colors := make([]uint8, w*h)
+	bpp := c.BitDepth.Planes()
+	c.forEachBit(func(i int, mask byte, ix, iy, p int) {
+		if p < bpp && src[i]&mask != 0 {
+			colors[iy*w+ix] |= 1 << p
+		}
+	})
+	for iy := 0; iy < h; iy++ {
+		for ix := 0; ix < w; ix++ {
+			dst.SetColorIndex(x+ix, y+iy, colors[iy*w+ix])
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// TestBitLayoutEquivalence tests that BitLayout can express the other
+// codecs exactly, for every bit depth, when encoding and decoding.
+func TestBitLayoutEquivalence(t *testing.T) {
+	src := newTestImage(0, 0, packedWordTestPix)
+
+	type codecs struct {
+		name   string
+		codec  func(bd tileconv.BitDepth) tileconv.Codec
+		layout string
+	}
+	tests := []codecs{
+		{"Packed", func(bd tileconv.BitDepth) tileconv.Codec {
+			return tileconv.Packed{BitDepth: bd}
+		}, ""},
+		{"RowPlanar", func(bd tileconv.BitDepth) tileconv.Codec {
+			return tileconv.RowPlanar{BitDepth: bd}
+		}, "order=y,p,x"},
+		{"TilePlanar", func(bd tileconv.BitDepth) tileconv.Codec {
+			return tileconv.TilePlanar{BitDepth: bd}
+		}, "order=p,y,x"},
+		{"TileRowPairPlanar", func(bd tileconv.BitDepth) tileconv.Codec {
+			return tileconv.TileRowPairPlanar{BitDepth: bd}
+		}, "order=g,y,p,x group=2"},
+		{"NGP", func(bd tileconv.BitDepth) tileconv.Codec {
+			return tileconv.PackedWord{BitDepth: bd}
+		}, "order=y,x,-p word=2"},
+		{"VB", func(bd tileconv.BitDepth) tileconv.Codec {
+			return tileconv.PackedWord{BitDepth: bd, LowFirst: true}
+		}, "order=y,x,p; word=2; bits=lsb"},
+		{"PackedWord BE", func(bd tileconv.BitDepth) tileconv.Codec {
+			return tileconv.PackedWord{
+				BitDepth: bd, WordSize: 4, BigEndian: true,
+			}
+		}, "order=y,x,-p word=4 endian=big"},
+	}
+
+	for _, tc := range tests {
+		layout, err := tileconv.ParseBitLayout(tc.layout)
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", tc.name, err)
+		}
+		for bd := tileconv.BD1; bd <= tileconv.BD8; bd++ {
+			codec := tc.codec(bd)
+			layout.BitDepth = bd
+			verify(t, tc.name+": bad size", layout.Size(), codec.Size())
+
+			want := make([]byte, codec.Size())
+			codec.Encode(src, 0, 0, want)
+			got := make([]byte, layout.Size())
+			layout.Encode(src, 0, 0, got)
+			if !bytes.Equal(got, want) {
+				t.Errorf(
+					"%s BD%v: bad encoded data:\nwant: %v\n got: %v",
+					tc.name, bd, want, got,
+				)
+			}
+
+			// Decode data with all bits set to check the padding too.
+			data := bytes.Repeat([]byte{0xA5, 0xFF, 0x3C}, len(got))
+			wantImg := newTestImage(0, 0, nil)
+			codec.Decode(data, wantImg, 0, 0)
+			gotImg := newTestImage(0, 0, nil)
+			layout.Decode(data, gotImg, 0, 0)
+			if !bytes.Equal(gotImg.Pix, wantImg.Pix) {
+				t.Errorf("%s BD%v: bad decoded pixels", tc.name, bd)
+			}
+		}
+	}
+}
+
+func TestBitLayoutTileSize(t *testing.T) {
+	c, err := tileconv.ParseBitLayout(`
+		# 16x16 4bpp, with 16-bit big-endian plane words per row.
+		bpp=4 tile=16x16
+		order=y,p,x
+		word=2 endian=big
+	`)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	verify(t, "bad size", c.Size(), 128)
+	w, h := tileconv.TileSize(c)
+	verify(t, "bad tile size", [2]int{w, h}, [2]int{16, 16})
+	verify(
+		t, "bad string", c.String(),
+		"bpp=4 tile=16x16 order=y,p,x word=2 endian=big",
+	)
+
+	src := image.NewPaletted(image.Rect(0, 0, 16, 16), newTestPalette())
+	for x := 0; x < 16; x++ {
+		src.SetColorIndex(x, 0, uint8(x))
+	}
+	got := make([]byte, c.Size())
+	c.Encode(src, 0, 0, got)
+	verify(t, "bad row 0", got[:8], []byte{
+		0b01010101, 0b01010101,
+		0b00110011, 0b00110011,
+		0b00001111, 0b00001111,
+		0b00000000, 0b11111111,
+	})
+	verify(t, "bad rest", got[8:], make([]byte, c.Size()-8))
+
+	dec := image.NewPaletted(src.Rect, src.Palette)
+	c.Decode(got, dec, 0, 0)
+	verify(t, "bad decoded pixels", dec.Pix, src.Pix)
+}
+
+func TestParseBitLayout_Errors(t *testing.T) {
+	for _, s := range []string{
+		"order",
+		"foo=1",
+		"bpp=9",
+		"tile=8",
+		"order=y,x",
+		"order=y,x,p,x",
+		"order=y,x,q",
+		"order=g,y,x,p",
+		"order=y,x,p group=2",
+		"word=-1",
+		"endian=middle",
+		"bits=both",
+	} {
+		if _, err := tileconv.ParseBitLayout(s); err == nil {
+			t.Errorf("missing error for %q", s)
+		}
+	}
+}
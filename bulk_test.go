@@ -0,0 +1,110 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// singleCodec hides the BulkCodec methods of a codec, to make Encode
+// and Decode use it one tile at a time.
+type singleCodec struct {
+	tileconv.Codec
+}
+
+// countingBulkCodec is a BulkCodec that records how it was called.
+type countingBulkCodec struct {
+	tileconv.RowPlanar
+	calls *[]string
+}
+
+var _ tileconv.BulkCodec = countingBulkCodec{}
+
+func (c countingBulkCodec) record(what string, x, y, n int) {
+	*c.calls = append(*c.calls, fmt.Sprintf("%s (%d,%d) %d", what, x, y, n))
+}
+
+func (c countingBulkCodec) Encode(
+	src tileconv.SourceImage, x, y int, dst []byte,
+) {
+	c.record("encode", x, y, 1)
+	c.RowPlanar.Encode(src, x, y, dst)
+}
+
+func (c countingBulkCodec) Decode(
+	src []byte, dst tileconv.DestImage, x, y int,
+) {
+	c.record("decode", x, y, 1)
+	c.RowPlanar.Decode(src, dst, x, y)
+}
+
+func (c countingBulkCodec) EncodeTiles(
+	src *image.Paletted, x, y, n int, dst []byte,
+) {
+	c.record("encodeTiles", x, y, n)
+	c.RowPlanar.EncodeTiles(src, x, y, n, dst)
+}
+
+func (c countingBulkCodec) DecodeTiles(
+	src []byte, dst *image.Paletted, x, y, n int,
+) {
+	c.record("decodeTiles", x, y, n)
+	c.RowPlanar.DecodeTiles(src, dst, x, y, n)
+}
+
+func TestEncode_Bulk(t *testing.T) {
+	// 3 full tiles and a partial one per row; 2 full rows and a partial.
+	src := newRandomSheet(30, 20, 1).SubImage(
+		image.Rect(2, 1, 30, 20),
+	).(*image.Paletted)
+
+	var calls []string
+	codec := countingBulkCodec{
+		RowPlanar: tileconv.RowPlanar{BitDepth: tileconv.BD2},
+		calls:     &calls,
+	}
+
+	var got, want bytes.Buffer
+	if err := tileconv.Encode(src, &got, codec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := tileconv.Encode(src, &want, singleCodec{codec.RowPlanar})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Errorf("bad encoded data:\nwant: %v\n got: %v", want, got)
+	}
+
+	verify(t, "bad calls", calls, []string{
+		"encodeTiles (2,1) 3", "encode (26,1) 1",
+		"encodeTiles (2,9) 3", "encode (26,9) 1",
+		"encode (2,17) 1", "encode (10,17) 1",
+		"encode (18,17) 1", "encode (26,17) 1",
+	})
+}
+
+func TestDecode_Bulk(t *testing.T) {
+	var calls []string
+	codec := countingBulkCodec{
+		RowPlanar: tileconv.RowPlanar{BitDepth: tileconv.BD2},
+		calls:     &calls,
+	}
+
+	// Enough data for 6 tiles and a bit, so it runs out mid-row.
+	data := newRandomSheet(16*6+5, 1, 2).Pix
+
+	got := newRandomSheet(28, 19, 3)
+	tileconv.Decode(data, got, codec)
+	want := newRandomSheet(28, 19, 3)
+	tileconv.Decode(data, want, singleCodec{codec.RowPlanar})
+	verify(t, "bad decoded pixels", got.Pix, want.Pix)
+
+	verify(t, "bad calls", calls, []string{
+		"decodeTiles (0,0) 3", "decode (24,0) 1",
+		"decodeTiles (0,8) 2",
+	})
+}
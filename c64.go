@@ -0,0 +1,177 @@
+package tileconv
+
+import (
+	"image/color"
+)
+
+// C64Palette is the 16-color palette of the Commodore 64, using the
+// commonly used colors measured by Philip "Pepto" Timmermann.
+var C64Palette = color.Palette{
+	color.RGBA{0x00, 0x00, 0x00, 0xFF}, // black
+	color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, // white
+	color.RGBA{0x68, 0x37, 0x2B, 0xFF}, // red
+	color.RGBA{0x70, 0xA4, 0xB2, 0xFF}, // cyan
+	color.RGBA{0x6F, 0x3D, 0x86, 0xFF}, // purple
+	color.RGBA{0x58, 0x8D, 0x43, 0xFF}, // green
+	color.RGBA{0x35, 0x28, 0x79, 0xFF}, // blue
+	color.RGBA{0xB8, 0xC7, 0x6F, 0xFF}, // yellow
+	color.RGBA{0x6F, 0x4F, 0x25, 0xFF}, // orange
+	color.RGBA{0x43, 0x39, 0x00, 0xFF}, // brown
+	color.RGBA{0x9A, 0x67, 0x59, 0xFF}, // light red
+	color.RGBA{0x44, 0x44, 0x44, 0xFF}, // dark grey
+	color.RGBA{0x6C, 0x6C, 0x6C, 0xFF}, // grey
+	color.RGBA{0x9A, 0xD2, 0x84, 0xFF}, // light green
+	color.RGBA{0x6C, 0x5E, 0xB5, 0xFF}, // light blue
+	color.RGBA{0x95, 0x95, 0x95, 0xFF}, // light grey
+}
+
+// C64Multicolor is a Codec for Commodore 64 multicolor characters.
+//
+// Each 8x8 character cell holds 4x8 logical pixels with 2 bits each,
+// stored as one byte per row, with the leftmost pixel in the high bits.
+// Hi-res characters can instead use RowPlanar with BD1.
+//
+// Since the logical pixels are twice as wide as they are tall, they can
+// either be treated as two image pixels each (with WidePixels), making
+// the tile 8x8 image pixels, or as a single image pixel, making the
+// tile 4x8 image pixels. When encoding wide pixels, only the left image
+// pixel of each pair is used.
+type C64Multicolor struct {
+	WidePixels bool
+}
+
+var _ Codec = C64Multicolor{}
+var _ TileSizer = C64Multicolor{}
+
+// Size implements Codec, returning the size of a tile.
+func (c C64Multicolor) Size() int {
+	return 8
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c C64Multicolor) TileSize() (w, h int) {
+	if c.WidePixels {
+		return 8, 8
+	}
+	return 4, 8
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c C64Multicolor) Encode(src SourceImage, x, y int, dst []byte) {
+	encodeBitRows(src, x, y, dst, c64Layout(4, 8, BD2, c.WidePixels))
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c C64Multicolor) Decode(src []byte, dst DestImage, x, y int) {
+	decodeBitRows(src, dst, x, y, c64Layout(4, 8, BD2, c.WidePixels))
+}
+
+// C64Sprite is a Codec for Commodore 64 sprites.
+//
+// Hi-res sprites are 24x21 pixels with 1 bit each, while multicolor
+// sprites are 12x21 logical pixels with 2 bits each, where the logical
+// pixels can be treated as two image pixels wide (like C64Multicolor).
+//
+// Either way, each row is stored as 3 bytes, with the leftmost pixel in
+// the high bits, for a total of 63 bytes. Since sprites are always
+// stored 64 bytes apart in memory, each tile includes an extra unused
+// byte at the end, which is written as zero and ignored when decoding.
+type C64Sprite struct {
+	Multicolor bool
+	WidePixels bool
+}
+
+var _ Codec = C64Sprite{}
+var _ TileSizer = C64Sprite{}
+
+// Size implements Codec, returning the size of a tile.
+func (c C64Sprite) Size() int {
+	return 64
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c C64Sprite) TileSize() (w, h int) {
+	return c.layout().Width * c.layout().Scale, 21
+}
+
+func (c C64Sprite) layout() bitRowLayout {
+	if c.Multicolor {
+		return c64Layout(12, 21, BD2, c.WidePixels)
+	}
+	return c64Layout(24, 21, BD1, false)
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c C64Sprite) Encode(src SourceImage, x, y int, dst []byte) {
+	encodeBitRows(src, x, y, dst, c.layout())
+	dst[63] = 0
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c C64Sprite) Decode(src []byte, dst DestImage, x, y int) {
+	decodeBitRows(src, dst, x, y, c.layout())
+}
+
+// bitRowLayout describes a tile that is stored as a sequence of rows of
+// packed pixels, with the leftmost pixel in the high bits of each byte.
+type bitRowLayout struct {
+	// Width and Height are the size of the tile, in logical pixels.
+	Width, Height int
+	// BitDepth is the number of bits per pixel; it must divide 8.
+	BitDepth BitDepth
+	// Scale is the width of each logical pixel, in image pixels.
+	Scale int
+}
+
+func c64Layout(w, h int, bd BitDepth, wide bool) bitRowLayout {
+	l := bitRowLayout{Width: w, Height: h, BitDepth: bd, Scale: 1}
+	if wide {
+		l.Scale = 2
+	}
+	return l
+}
+
+// encodeBitRows encodes a tile with the given layout.
+func encodeBitRows(
+	src SourceImage, x, y int, dst []byte, l bitRowLayout,
+) {
+	bpp, mask := l.BitDepth.Planes(), l.BitDepth.ColorMask()
+	di := 0
+	for iy := 0; iy < l.Height; iy++ {
+		data, bits := byte(0), 0
+		for ix := 0; ix < l.Width; ix++ {
+			color := mask & src.ColorIndexAt(x+ix*l.Scale, y+iy)
+			data = (data << bpp) | color
+			bits += bpp
+			if bits == 8 {
+				dst[di] = data
+				di++
+				data, bits = 0, 0
+			}
+		}
+	}
+}
+
+// decodeBitRows decodes a tile with the given layout.
+func decodeBitRows(
+	src []byte, dst DestImage, x, y int, l bitRowLayout,
+) {
+	bpp := l.BitDepth.Planes()
+	si := 0
+	for iy := 0; iy < l.Height; iy++ {
+		var d byte
+		bits := 0
+		for ix := 0; ix < l.Width; ix++ {
+			if bits == 0 {
+				d, bits = src[si], 8
+				si++
+			}
+			color := d >> (8 - bpp)
+			d <<= bpp
+			bits -= bpp
+			for s := 0; s < l.Scale; s++ {
+				dst.SetColorIndex(x+ix*l.Scale+s, y+iy, color)
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestC64MulticolorSize(t *testing.T) {
+	for _, wide := range []bool{false, true} {
+		c := tileconv.C64Multicolor{WidePixels: wide}
+		verify(t, "bad size", c.Size(), 8)
+		w, h := tileconv.TileSize(c)
+		wantW := 4
+		if wide {
+			wantW = 8
+		}
+		verify(t, "bad tile size", [2]int{w, h}, [2]int{wantW, 8})
+	}
+}
+
+func TestC64MulticolorEncode(t *testing.T) {
+	// This uses the same pixel data as for the RowPlanar tests.
+	srcPix := [][]uint8{
+		{0x01, 0x94, 0xFD, 0xC2, 0xFA, 0x2F, 0xFC, 0xC0},
+		{0x41, 0xD3, 0xFF, 0x12, 0x04, 0x5B, 0x73, 0xC8},
+		{0x6E, 0x4F, 0xF9, 0x5F, 0xF6, 0x62, 0xA5, 0xEE},
+		{0xE8, 0x2A, 0xBD, 0xF4, 0x4A, 0x2D, 0x0B, 0x75},
+		{0xFB, 0x18, 0x0D, 0xAF, 0x48, 0xA7, 0x9E, 0xE0},
+		{0xB1, 0x0D, 0x39, 0x46, 0x51, 0x85, 0x0F, 0xD4},
+		{0xA1, 0x78, 0x89, 0x2E, 0xE2, 0x85, 0xEC, 0xE1},
+		{0x51, 0x14, 0x55, 0x78, 0x08, 0x75, 0xD6, 0x4E},
+	}
+
+	runCodecEncodeTests(
+		t, "narrow", tileconv.C64Multicolor{},
+		srcPix, []byte{
+			0b01000110,
+			0b01111110,
+			0b10110111,
+			0b00100100,
+			0b11000111,
+			0b01010110,
+			0b01000110,
+			0b01000100,
+		},
+	)
+
+	runCodecEncodeTests(
+		t, "wide", tileconv.C64Multicolor{WidePixels: true},
+		srcPix, []byte{
+			0b01011000,
+			0b01110011,
+			0b10011001,
+			0b00011011,
+			0b11010010,
+			0b01010111,
+			0b01011000,
+			0b01010010,
+		},
+	)
+}
+
+func TestC64MulticolorDecode(t *testing.T) {
+	src := []byte{
+		0b01000110,
+		0b01111110,
+		0b10110111,
+		0b00100100,
+		0b11000111,
+		0b01010110,
+		0b01000110,
+		0b01000100,
+	}
+	narrowPix := [][]uint8{
+		{1, 0, 1, 2},
+		{1, 3, 3, 2},
+		{2, 3, 1, 3},
+		{0, 2, 1, 0},
+		{3, 0, 1, 3},
+		{1, 1, 1, 2},
+		{1, 0, 1, 2},
+		{1, 0, 1, 0},
+	}
+	widePix := make([][]uint8, len(narrowPix))
+	for i, row := range narrowPix {
+		for _, p := range row {
+			widePix[i] = append(widePix[i], p, p)
+		}
+	}
+
+	runCodecDecodeTests(
+		t, "narrow", tileconv.C64Multicolor{}, src, narrowPix,
+	)
+	runCodecDecodeTests(
+		t, "wide", tileconv.C64Multicolor{WidePixels: true}, src, widePix,
+	)
+}
+
+func TestC64SpriteSize(t *testing.T) {
+	check := func(c tileconv.C64Sprite, wantW int) {
+		t.Helper()
+		verify(t, "bad size", c.Size(), 64)
+		w, h := tileconv.TileSize(c)
+		verify(t, "bad tile size", [2]int{w, h}, [2]int{wantW, 21})
+	}
+	check(tileconv.C64Sprite{}, 24)
+	check(tileconv.C64Sprite{WidePixels: true}, 24)
+	check(tileconv.C64Sprite{Multicolor: true}, 12)
+	check(tileconv.C64Sprite{Multicolor: true, WidePixels: true}, 24)
+}
+
+func TestC64SpriteEncode(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 24, 21), newTestPalette())
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 5 / 3)
+	}
+
+	check := func(name string, c tileconv.C64Sprite, want func(i int) byte) {
+		t.Helper()
+		got := make([]byte, c.Size())
+		for i := range got {
+			got[i] = 0xFF
+		}
+		c.Encode(img, 0, 0, got)
+		for i := 0; i < 63; i++ {
+			if w := want(i); got[i] != w {
+				t.Errorf("%s: byte %v: want %08b, got %08b", name, i, w, got[i])
+			}
+		}
+		if got[63] != 0 {
+			t.Errorf("%s: padding byte not cleared: %v", name, got[63])
+		}
+	}
+
+	check("hires", tileconv.C64Sprite{}, func(i int) byte {
+		var b byte
+		for p := 0; p < 8; p++ {
+			b = b<<1 | img.ColorIndexAt(i%3*8+p, i/3)&1
+		}
+		return b
+	})
+	check("multicolor", tileconv.C64Sprite{Multicolor: true}, func(i int) byte {
+		var b byte
+		for p := 0; p < 4; p++ {
+			b = b<<2 | img.ColorIndexAt(i%3*4+p, i/3)&3
+		}
+		return b
+	})
+	check("wide", tileconv.C64Sprite{Multicolor: true, WidePixels: true},
+		func(i int) byte {
+			var b byte
+			for p := 0; p < 4; p++ {
+				b = b<<2 | img.ColorIndexAt(i%3*8+p*2, i/3)&3
+			}
+			return b
+		},
+	)
+}
+
+// TestC64SpriteSheet tests that Encode and Decode use the tile size of
+// a codec that is not 8x8, by round-tripping a sheet of sprites.
+func TestC64SpriteSheet(t *testing.T) {
+	codecs := map[string]tileconv.C64Sprite{
+		"hires":      {},
+		"multicolor": {Multicolor: true},
+	}
+	for name, c := range codecs {
+		t.Run(name, func(t *testing.T) {
+			w, h := tileconv.TileSize(c)
+			pal := newTestPalette()
+			mask := uint8(1)
+			if c.Multicolor {
+				mask = 3
+			}
+
+			src := image.NewPaletted(image.Rect(0, 0, w*2, h*2), pal)
+			for i := range src.Pix {
+				src.Pix[i] = uint8(i*7+i/5) & mask
+			}
+
+			var buf bytes.Buffer
+			if err := tileconv.Encode(src, &buf, c); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			verify(t, "bad data length", buf.Len(), 4*64)
+
+			// The second sprite must be the top-right one.
+			one := make([]byte, 64)
+			c.Encode(src, w, 0, one)
+			verify(t, "bad second sprite", buf.Bytes()[64:128], one)
+
+			got := image.NewPaletted(src.Rect, pal)
+			tileconv.Decode(buf.Bytes(), got, c)
+			verify(t, "bad decoded pixels", got.Pix, src.Pix)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package tileconv
+
+import (
+	"fmt"
+	"image"
+)
+
+// ColorClash describes a cell of an image that uses more colors than
+// the target format allows within a single cell.
+type ColorClash struct {
+	// Cell is the area of the image that is covered by the cell.
+	Cell image.Rectangle
+
+	// Colors holds the color indexes used in the cell, in ascending
+	// order, excluding any colors that are shared by all cells.
+	Colors []uint8
+
+	// Max is the max number of colors that the cell is allowed to use.
+	Max int
+
+	// Reason describes why the colors clash, if it is not simply that
+	// there are more than Max of them.
+	Reason string
+}
+
+// String returns a description of the clash.
+func (c ColorClash) String() string {
+	if c.Reason != "" {
+		return fmt.Sprintf(
+			"cell at %v uses colors %v: %s", c.Cell.Min, c.Colors, c.Reason,
+		)
+	}
+	return fmt.Sprintf(
+		"cell at %v uses %v colors %v, max is %v",
+		c.Cell.Min, len(c.Colors), c.Colors, c.Max,
+	)
+}
+
+// ColorClashError is the error returned when an image can not be
+// encoded because some of its cells use too many colors.
+type ColorClashError struct {
+	Clashes []ColorClash
+}
+
+// Error implements error.
+func (e *ColorClashError) Error() string {
+	if len(e.Clashes) == 1 {
+		return "color clash: " + e.Clashes[0].String()
+	}
+	return fmt.Sprintf(
+		"color clash in %v cells; first %v",
+		len(e.Clashes), e.Clashes[0],
+	)
+}
+
+// cellColors returns the distinct color indexes used in the given area
+// of the image, in ascending order, looking only at every step'th pixel
+// of each row.
+func cellColors(src SourceImage, r image.Rectangle, step int) []uint8 {
+	var used [256]bool
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x += step {
+			used[src.ColorIndexAt(x, y)] = true
+		}
+	}
+	var colors []uint8
+	for i, u := range used {
+		if u {
+			colors = append(colors, uint8(i))
+		}
+	}
+	return colors
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type AppleHiResArgs struct {
+	Image  string `arg:"positional,required" help:"image file"`
+	Screen string `arg:"positional,required" help:"hi-res screen memory file"`
+	Decode bool   `arg:"-d" help:"decode the screen file into the image"`
+}
+
+func (AppleHiResArgs) Description() string {
+	return "Converts between a 280x192 image and an Apple II hi-res " +
+		"screen memory dump.\nIn the image, bit 0 of each color is the " +
+		"pixel and bit 1 is the palette\nbit, which must be the same " +
+		"for each group of 7 pixels that share a byte;\nany clashes are " +
+		"listed. By default, this encodes the image into the file."
+}
+
+func runAppleHiResCommand(argv []string) error {
+	var args AppleHiResArgs
+	mustParse("a2hgr", &args, argv)
+
+	if args.Decode {
+		if err := checkImageFormat(args.Image); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(args.Screen)
+		if err != nil {
+			return err
+		}
+		img, err := tileconv.DecodeAppleHiRes(data)
+		if err != nil {
+			return err
+		}
+		return saveImage(args.Image, img)
+	}
+
+	img, err := loadImage(args.Image)
+	if err != nil {
+		return err
+	}
+	data, err := tileconv.EncodeAppleHiRes(img)
+	if err != nil {
+		return withClashDetails(err)
+	}
+	return os.WriteFile(args.Screen, data, 0o666)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type BuildArgs struct {
+	Manifest string `arg:"positional,required" help:"manifest file (JSON)"`
+
+	Jobs     int           `arg:"-j" help:"number of parallel jobs; default CPU count"`
+	Force    bool          `arg:"-B" help:"rebuild even outputs that are up to date"`
+	Watch    bool          `arg:"-w" help:"keep running, rebuilding when inputs change"`
+	Interval watchInterval `default:"500ms" help:"how often to check for changes with --watch"`
+}
+
+func (BuildArgs) Description() string {
+	return "Converts all the assets listed in a manifest file, skipping " +
+		"those whose\noutputs are newer than both their input and the " +
+		"manifest itself."
+}
+
+func (BuildArgs) Epilogue() string {
+	return `Manifest format (paths are relative to the manifest file):
+    {
+        "defaults": {"format": "tp", "bpp": 2},
+        "assets": [
+            {"input": "font.png", "output": "font.chr"},
+            {"input": "sprites.png", "output": "sprites.bin", "bpp": 4},
+            {"input": "dump.bin", "output": "dump.png", "decode": true}
+        ]
+    }
+
+` + formatsHelp()
+}
+
+func runBuildCommand(argv []string) error {
+	var args BuildArgs
+	mustParse("build", &args, argv)
+	return runBuild(args)
+}
+
+// Manifest is the contents of a build manifest file.
+type Manifest struct {
+	// Defaults holds the default options for all the assets; any option
+	// not given by an asset is taken from here.
+	Defaults ManifestAsset   `json:"defaults"`
+	Assets   []ManifestAsset `json:"assets"`
+}
+
+// ManifestAsset describes a single conversion in a build manifest.
+type ManifestAsset struct {
+	Input  string      `json:"input"`
+	Output string      `json:"output"`
+	Decode *bool       `json:"decode"`
+	Format Format      `json:"format"`
+	Bpp    manifestBpp `json:"bpp"`
+}
+
+// manifestBpp is a BitDepth that can be given as either a JSON number
+// or a JSON string.
+type manifestBpp tileconv.BitDepth
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *manifestBpp) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	return (*tileconv.BitDepth)(b).UnmarshalText([]byte(s))
+}
+
+// loadManifest reads the given manifest file, and returns the list of
+// conversions that it specifies, with defaults and paths resolved.
+func loadManifest(fn string) ([]Args, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	dir := filepath.Dir(fn)
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	list := make([]Args, 0, len(m.Assets))
+	for i, a := range m.Assets {
+		args := Args{
+			Input:  a.Input,
+			Output: a.Output,
+			Format: a.Format,
+			Bpp:    tileconv.BitDepth(a.Bpp),
+		}
+		// Decode is a pointer, so that an asset can turn it off again.
+		if a.Decode != nil {
+			args.Decode = *a.Decode
+		} else if m.Defaults.Decode != nil {
+			args.Decode = *m.Defaults.Decode
+		}
+		if args.Format == "" {
+			args.Format = m.Defaults.Format
+		}
+		if args.Bpp == 0 {
+			args.Bpp = tileconv.BitDepth(m.Defaults.Bpp)
+		}
+
+		switch {
+		case args.Input == "" || args.Output == "":
+			err = fmt.Errorf("missing input or output")
+		case args.Format == "":
+			err = fmt.Errorf("missing format")
+		case args.Bpp == 0:
+			err = fmt.Errorf("missing bpp")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: asset %v: %w", fn, i, err)
+		}
+
+		args.Input = resolve(args.Input)
+		args.Output = resolve(args.Output)
+		list = append(list, args)
+	}
+
+	return list, nil
+}
+
+func runBuild(args BuildArgs) error {
+	if args.Jobs < 1 {
+		args.Jobs = runtime.NumCPU()
+	}
+
+	if args.Watch {
+		return watchFiles(
+			func() []string { return buildWatchFiles(args.Manifest) },
+			args.Interval, func() error { return buildManifest(args) },
+		)
+	}
+
+	return buildManifest(args)
+}
+
+// buildWatchFiles returns the files to watch for changes when building
+// the given manifest: the manifest itself, and all the input files.
+func buildWatchFiles(manifest string) []string {
+	files := []string{manifest}
+	// If the manifest can't be loaded, buildManifest will report it.
+	list, _ := loadManifest(manifest)
+	for _, a := range list {
+		files = append(files, a.Input)
+	}
+	return files
+}
+
+// buildManifest does a single build of all the out-of-date assets.
+func buildManifest(args BuildArgs) error {
+	list, err := loadManifest(args.Manifest)
+	if err != nil {
+		return err
+	}
+
+	manifestTime, err := modTime(args.Manifest)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(list))
+	built := make([]bool, len(list))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < args.Jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				a := list[i]
+				if !args.Force {
+					ok, err := upToDate(a.Output, a.Input, manifestTime)
+					if ok || err != nil {
+						errs[i] = err
+						continue
+					}
+				}
+				errs[i] = buildAsset(a)
+				built[i] = errs[i] == nil
+			}
+		}()
+	}
+	for i := range list {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	count, failed := 0, 0
+	for i, a := range list {
+		if errs[i] != nil {
+			watchLog("Error: %s: %v", a.Output, errs[i])
+			failed++
+		} else if built[i] {
+			watchLog("Wrote %s", a.Output)
+			count++
+		}
+	}
+	watchLog(
+		"Built %v, up to date %v, failed %v",
+		count, len(list)-count-failed, failed,
+	)
+
+	if failed > 0 {
+		return fmt.Errorf("%v of %v assets failed to build", failed, len(list))
+	}
+	return nil
+}
+
+// buildAsset does the conversion of a single asset, creating the output
+// directory if necessary.
+//
+// If the conversion fails, the output file is removed, since it may have
+// been left empty or truncated, and would otherwise look up to date.
+func buildAsset(a Args) error {
+	if err := os.MkdirAll(filepath.Dir(a.Output), 0o777); err != nil {
+		return err
+	}
+	if err := convertFile(a); err != nil {
+		if e := os.Remove(a.Output); e != nil && !os.IsNotExist(e) {
+			watchLog("Error: %s: %v", a.Output, e)
+		}
+		return err
+	}
+	return nil
+}
+
+// upToDate returns true if the output file exists, and is newer than
+// both the input file and the given time.
+func upToDate(output, input string, after time.Time) (bool, error) {
+	outTime, err := modTime(output)
+	if err != nil || outTime.IsZero() {
+		return false, err
+	}
+	inTime, err := modTime(input)
+	if err != nil {
+		return false, err
+	}
+	if inTime.IsZero() {
+		return false, fmt.Errorf("input file not found: %s", input)
+	}
+	return outTime.After(inTime) && outTime.After(after), nil
+}
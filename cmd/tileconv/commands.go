@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+)
+
+// command is a subcommand of the CLI, which is selected by giving its
+// name as the first argument. Without a subcommand, the CLI converts a
+// single file between an image and tile data.
+type command struct {
+	Name string
+	Help string
+	run  func(argv []string) error
+}
+
+// commands is the list of known subcommands.
+var commands = []command{
+	{
+		Name: "compare",
+		Help: "decode with many formats into one comparison sheet",
+		run:  runCompareCommand,
+	},
+	{
+		Name: "view",
+		Help: "interactively browse the tiles in a binary file",
+		run:  runViewCommand,
+	},
+	{
+		Name: "serve",
+		Help: "start a local HTTP server for converting in a browser",
+		run:  runServeCommand,
+	},
+	{
+		Name: "build",
+		Help: "convert all the assets listed in a manifest file",
+		run:  runBuildCommand,
+	},
+	{
+		Name: "convert",
+		Help: "convert tile data directly between two formats",
+		run:  runConvertCommand,
+	},
+	{
+		Name: "sprites",
+		Help: "slice a sprite sheet into separately encoded sprites",
+		run:  runSpritesCommand,
+	},
+	{
+		Name: "oam",
+		Help: "cover a frame with hardware sprites, listing their tiles",
+		run:  runOAMCommand,
+	},
+	{
+		Name: "planes",
+		Help: "split an image into bit planes, or merge them into one",
+		run:  runPlanesCommand,
+	},
+	{
+		Name: "mode7",
+		Help: "convert SNES Mode 7 interleaved tile/map VRAM data",
+		run:  runMode7Command,
+	},
+	{
+		Name: "koala",
+		Help: "convert C64 Koala Painter multicolor bitmaps",
+		run:  runKoalaCommand,
+	},
+	{
+		Name: "zxscr",
+		Help: "convert ZX Spectrum screen files, checking for clashes",
+		run:  runSpectrumCommand,
+	},
+	{
+		Name: "a2hgr",
+		Help: "convert Apple II hi-res screens, checking palette bits",
+		run:  runAppleHiResCommand,
+	},
+	{
+		Name: "neogeo",
+		Help: "convert Neo Geo sprites split across a C-ROM pair",
+		run:  runNeoGeoCommand,
+	},
+	{
+		Name: "tms9918",
+		Help: "convert TMS9918 (MSX1) pattern and color tables",
+		run:  runTMS9918Command,
+	},
+}
+
+// findCommand returns the subcommand named by the first argument.
+func findCommand(argv []string) (command, bool) {
+	if len(argv) == 0 {
+		return command{}, false
+	}
+	for _, c := range commands {
+		if c.Name == argv[0] {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// commandsHelp returns the help text that lists the known subcommands.
+func commandsHelp() string {
+	var b strings.Builder
+	b.WriteString("Subcommands (use \"tileconv <command> --help\" for more):")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "\n    %-10s : %s", c.Name, c.Help)
+	}
+	return b.String()
+}
+
+// mustParse parses the arguments of a subcommand into dest, exiting the
+// program on errors or when help was requested (like arg.MustParse).
+func mustParse(name string, dest interface{}, argv []string) {
+	p, err := arg.NewParser(arg.Config{Program: "tileconv " + name}, dest)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	p.MustParse(argv)
+}
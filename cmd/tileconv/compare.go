@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type CompareArgs struct {
+	Input  string `arg:"positional,required" help:"input file"`
+	Output string `arg:"positional,required" help:"output file or dir"`
+
+	Formats []Format            `arg:"-f" help:"formats; default all"`
+	Bpp     []tileconv.BitDepth `arg:"-b" help:"bit depths; default all"`
+
+	Offset int  `arg:"-o" help:"input byte offset to start at"`
+	Tiles  int  `arg:"-t" default:"256" help:"max tiles per combination"`
+	Cols   int  `arg:"-c" default:"16" help:"number of tiles per row"`
+	Scale  int  `arg:"-s" default:"2" help:"scale factor for the labels"`
+	Split  bool `help:"write one file per combination into dir"`
+}
+
+func (CompareArgs) Description() string {
+	return "Decodes the same input data with every combination of the " +
+		"given tile data\nformats and bit depths, and renders them " +
+		"side-by-side into one labeled\nimage, with one row per format " +
+		"and one column per bit depth."
+}
+
+func (CompareArgs) Epilogue() string {
+	return formatsHelp()
+}
+
+func runCompareCommand(argv []string) error {
+	var args CompareArgs
+	mustParse("compare", &args, argv)
+	return runCompare(args)
+}
+
+// comparePanel is one decoded format and bit depth combination. If the
+// format does not support the bit depth, Image is nil.
+type comparePanel struct {
+	Label string
+	Name  string
+	Image *image.Paletted
+}
+
+func runCompare(args CompareArgs) error {
+	if args.Cols < 1 || args.Tiles < 1 || args.Scale < 1 {
+		return fmt.Errorf("cols, tiles and scale must be positive")
+	}
+	if !args.Split {
+		if err := checkImageFormat(args.Output); err != nil {
+			return err
+		}
+	}
+
+	src, err := os.ReadFile(args.Input)
+	if err != nil {
+		return err
+	}
+	if args.Offset < 0 || args.Offset > len(src) {
+		return fmt.Errorf("offset %v is outside of the input", args.Offset)
+	}
+	src = src[args.Offset:]
+
+	fmts := args.Formats
+	if len(fmts) == 0 {
+		for _, f := range formats {
+			fmts = append(fmts, Format(f.Names[0]))
+		}
+	}
+	depths := args.Bpp
+	if len(depths) == 0 {
+		for bd := tileconv.BD1; bd <= tileconv.BD8; bd++ {
+			depths = append(depths, bd)
+		}
+	}
+
+	panels := make([][]comparePanel, len(fmts))
+	for i, f := range fmts {
+		fi := findFormat(string(f))
+		for _, bd := range depths {
+			if !fi.Supports(bd) {
+				// Leave an empty cell to keep the columns aligned.
+				panels[i] = append(panels[i], comparePanel{})
+				continue
+			}
+			codec, err := f.Codec(bd)
+			if err != nil {
+				return err
+			}
+
+			data := src
+			if max := args.Tiles * codec.Size(); len(data) > max {
+				data = data[:max]
+			}
+
+			panels[i] = append(panels[i], comparePanel{
+				Label: fmt.Sprintf("%s %vbpp", f.ShortName(), bd),
+				Name:  fmt.Sprintf("%s-%vbpp", f.ShortName(), bd),
+				Image: decodeSheet(data, codec, bd, args.Cols),
+			})
+		}
+	}
+
+	if args.Split {
+		return saveComparePanels(args.Output, panels)
+	}
+
+	return saveImage(args.Output, renderCompareSheet(panels, args.Scale))
+}
+
+// saveComparePanels writes each panel to a separate PNG file in dir.
+func saveComparePanels(dir string, panels [][]comparePanel) error {
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return err
+	}
+	for _, row := range panels {
+		for _, p := range row {
+			if p.Image == nil {
+				continue
+			}
+			fn := filepath.Join(dir, p.Name+".png")
+			if err := saveImage(fn, p.Image); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderCompareSheet renders the panels into a single image, with each
+// panel labeled and laid out in a grid matching the given rows.
+func renderCompareSheet(panels [][]comparePanel, scale int) *image.RGBA {
+	const margin = 4
+	var (
+		bgColor    = color.NRGBA{R: 32, G: 32, B: 96, A: 255}
+		labelColor = color.NRGBA{R: 255, G: 255, B: 160, A: 255}
+	)
+
+	// Find the size of each grid column and row.
+	labelHeight := textSize("", scale).Y
+	var colWidths, rowHeights []int
+	// Empty panels still get a column, to keep the grid aligned.
+	for _, row := range panels {
+		for len(colWidths) < len(row) {
+			colWidths = append(colWidths, 0)
+		}
+	}
+	for _, row := range panels {
+		height := 0
+		for x, p := range row {
+			if p.Image == nil {
+				continue
+			}
+			sz := p.Image.Bounds().Size()
+			if w := textSize(p.Label, scale).X; w > sz.X {
+				sz.X = w
+			}
+			if sz.X > colWidths[x] {
+				colWidths[x] = sz.X
+			}
+			if sz.Y > height {
+				height = sz.Y
+			}
+		}
+		rowHeights = append(rowHeights, labelHeight+height)
+	}
+
+	width, height := margin, margin
+	for _, w := range colWidths {
+		width += w + margin
+	}
+	for _, h := range rowHeights {
+		height += h + margin
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	bg := image.NewUniform(bgColor)
+	draw.Draw(img, img.Bounds(), bg, image.Point{}, draw.Src)
+
+	y := margin
+	for r, row := range panels {
+		x := margin
+		for c, p := range row {
+			if p.Image == nil {
+				x += colWidths[c] + margin
+				continue
+			}
+			drawText(img, image.Pt(x, y), p.Label, labelColor, scale)
+			at := image.Pt(x, y+labelHeight)
+			b := p.Image.Bounds()
+			draw.Draw(img, b.Sub(b.Min).Add(at), p.Image, b.Min, draw.Src)
+			x += colWidths[c] + margin
+		}
+		y += rowHeights[r] + margin
+	}
+
+	return img
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestCompareSkippedDepth(t *testing.T) {
+	// c64mc only supports 2bpp, so the 4bpp column is empty in every row.
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.bin")
+	if err := os.WriteFile(in, make([]byte, 256), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.png")
+	err := runCompare(CompareArgs{
+		Input: in, Output: out,
+		Formats: []Format{"c64mc"},
+		Bpp:     []tileconv.BitDepth{tileconv.BD2, tileconv.BD4},
+		Tiles:   256, Cols: 16, Scale: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(out); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type ConvertArgs struct {
+	Input  string `arg:"positional,required" help:"input tile data file"`
+	Output string `arg:"positional,required" help:"output tile data file"`
+
+	From  Format            `arg:"-f,required" help:"input tile data format"`
+	To    Format            `arg:"-t,required" help:"output tile data format"`
+	Bpp   tileconv.BitDepth `arg:"-b,required" help:"input bits per pixel"`
+	ToBpp tileconv.BitDepth `arg:"--to-bpp" help:"output bits per pixel, if different"`
+
+	AllowLoss bool `arg:"--allow-loss" help:"write the output even if colors are lost"`
+}
+
+func (ConvertArgs) Description() string {
+	return "Converts tile data directly from one tile data format to " +
+		"another, e.g. from\nGame Boy (rp, 2bpp) to NES (tp, 2bpp), " +
+		"without going through an image.\nIf the output has fewer bits " +
+		"per pixel, any tiles that use colors that do\nnot fit are listed, " +
+		"and nothing is written unless --allow-loss is given."
+}
+
+func (ConvertArgs) Epilogue() string {
+	return formatsHelp()
+}
+
+func runConvertCommand(argv []string) error {
+	var args ConvertArgs
+	mustParse("convert", &args, argv)
+
+	if args.ToBpp == 0 {
+		args.ToBpp = args.Bpp
+	}
+	from, err := args.From.Codec(args.Bpp)
+	if err != nil {
+		return err
+	}
+	to, err := args.To.Codec(args.ToBpp)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.ReadFile(args.Input)
+	if err != nil {
+		return err
+	}
+
+	dst, err := tileconv.Transcode(src, from, to)
+	var le *tileconv.LostColorsError
+	if args.AllowLoss && errors.As(err, &le) {
+		for _, l := range le.Tiles {
+			fmt.Fprintln(os.Stderr, "Warning: lost colors:", l)
+		}
+	} else if err != nil {
+		return withLostColorsDetails(err)
+	}
+
+	return os.WriteFile(args.Output, dst, 0o666)
+}
+
+// withLostColorsDetails prints the details of a *LostColorsError to
+// stderr, and returns the error.
+func withLostColorsDetails(err error) error {
+	var le *tileconv.LostColorsError
+	if errors.As(err, &le) {
+		for _, l := range le.Tiles {
+			fmt.Fprintln(os.Stderr, "Lost:", l)
+		}
+	}
+	return err
+}
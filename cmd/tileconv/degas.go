@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// This file implements loading and saving Atari ST Degas images, using
+// tileconv.WordPlanar for the screen data.
+
+// degasMode describes one of the Atari ST screen resolutions.
+type degasMode struct {
+	Width, Height int
+	BitDepth      tileconv.BitDepth
+}
+
+// degasModes holds the screen modes, indexed by the resolution number.
+var degasModes = []degasMode{
+	{320, 200, tileconv.BD4},
+	{640, 200, tileconv.BD2},
+	{640, 400, tileconv.BD1},
+}
+
+const (
+	degasPaletteSize = 16
+	degasHeaderSize  = 2 + degasPaletteSize*2
+	degasScreenSize  = 32000
+)
+
+// degasResolution returns the resolution number for the given file
+// name extension, or -1 if it is not a Degas file extension.
+func degasResolution(fn string) int {
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".pi1":
+		return 0
+	case ".pi2":
+		return 1
+	case ".pi3":
+		return 2
+	}
+	return -1
+}
+
+// degasCodec returns the codec for the screen memory of the given mode.
+func degasCodec(m degasMode) tileconv.WordPlanar {
+	return tileconv.WordPlanar{BitDepth: m.BitDepth, Height: 1}
+}
+
+func decodeDegas(r io.Reader) (*image.Paletted, error) {
+	data := make([]byte, degasHeaderSize+degasScreenSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("degas: %w", err)
+	}
+
+	res := int(binary.BigEndian.Uint16(data))
+	if res >= len(degasModes) {
+		return nil, fmt.Errorf("degas: unknown resolution: %v", res)
+	}
+	mode := degasModes[res]
+
+	// The file always has 16 palette entries, even if the mode uses
+	// fewer colors; we keep them all, to preserve them when saving.
+	pal := make(color.Palette, degasPaletteSize)
+	for i := range pal {
+		pal[i] = stColor(binary.BigEndian.Uint16(data[2+i*2:]))
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, mode.Width, mode.Height), pal)
+	tileconv.Decode(data[degasHeaderSize:], img, degasCodec(mode))
+	return img, nil
+}
+
+func writeDegas(out io.Writer, res int, img *image.Paletted) error {
+	mode := degasModes[res]
+	if len(img.Palette) > degasPaletteSize {
+		return fmt.Errorf(
+			"degas: palette has %v colors, max is %v",
+			len(img.Palette), degasPaletteSize,
+		)
+	}
+	if sz := img.Bounds().Size(); sz != image.Pt(mode.Width, mode.Height) {
+		return fmt.Errorf(
+			"degas: image must be %vx%v, not %vx%v",
+			mode.Width, mode.Height, sz.X, sz.Y,
+		)
+	}
+
+	data := make([]byte, degasHeaderSize, degasHeaderSize+degasScreenSize)
+	binary.BigEndian.PutUint16(data, uint16(res))
+	for i, c := range img.Palette {
+		binary.BigEndian.PutUint16(data[2+i*2:], toSTColor(c))
+	}
+
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+	return tileconv.Encode(img, out, degasCodec(mode))
+}
+
+// stColor converts an Atari ST(E) palette entry into a color. Each
+// channel has 4 bits, with the low bit stored above the other three
+// (for compatibility with the 3-bit channels of the original ST).
+func stColor(v uint16) color.Color {
+	ch := func(n uint16) uint8 {
+		n &= 15
+		return uint8((n&7)<<1|n>>3) * 17
+	}
+	return color.RGBA{ch(v >> 8), ch(v >> 4), ch(v), 0xFF}
+}
+
+// toSTColor converts a color into an Atari ST(E) palette entry.
+func toSTColor(c color.Color) uint16 {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	ch := func(v uint8) uint16 {
+		n := uint16(v) >> 4
+		return n>>1 | (n&1)<<3
+	}
+	return ch(rgba.R)<<8 | ch(rgba.G)<<4 | ch(rgba.B)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// glyphs is a tiny 3x5 pixel font, used for labeling generated images.
+//
+// Each glyph is 5 rows, with the 3 low bits of each row being the
+// pixels of that row, the most significant bit being the leftmost one.
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7}, '1': {2, 6, 2, 2, 7}, '2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 3, 1, 7}, '4': {5, 5, 7, 1, 1}, '5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7}, '7': {7, 1, 1, 2, 2}, '8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'A': {2, 5, 7, 5, 5}, 'B': {6, 5, 6, 5, 6}, 'C': {3, 4, 4, 4, 3},
+	'D': {6, 5, 5, 5, 6}, 'E': {7, 4, 6, 4, 7}, 'F': {7, 4, 6, 4, 4},
+	'G': {3, 4, 5, 5, 3}, 'H': {5, 5, 7, 5, 5}, 'I': {7, 2, 2, 2, 7},
+	'J': {1, 1, 1, 5, 2}, 'K': {5, 5, 6, 5, 5}, 'L': {4, 4, 4, 4, 7},
+	'M': {5, 7, 7, 5, 5}, 'N': {6, 5, 5, 5, 5}, 'O': {2, 5, 5, 5, 2},
+	'P': {6, 5, 6, 4, 4}, 'Q': {2, 5, 5, 6, 3}, 'R': {6, 5, 6, 5, 5},
+	'S': {3, 4, 2, 1, 6}, 'T': {7, 2, 2, 2, 2}, 'U': {5, 5, 5, 5, 7},
+	'V': {5, 5, 5, 5, 2}, 'W': {5, 5, 7, 7, 5}, 'X': {5, 5, 2, 5, 5},
+	'Y': {5, 5, 2, 2, 2}, 'Z': {7, 1, 2, 4, 7},
+	' ': {0, 0, 0, 0, 0}, '-': {0, 0, 7, 0, 0}, '.': {0, 0, 0, 0, 2},
+	':': {0, 2, 0, 2, 0}, '/': {1, 1, 2, 4, 4}, '_': {0, 0, 0, 0, 7},
+	'?': {6, 1, 2, 0, 2},
+}
+
+const (
+	// glyphWidth is the width of a glyph, including spacing.
+	glyphWidth = 4
+	// glyphHeight is the height of a glyph, including spacing.
+	glyphHeight = 6
+)
+
+// textSize returns the size of the given text when drawn by drawText.
+func textSize(text string, scale int) image.Point {
+	n := len([]rune(text))
+	return image.Pt(n*glyphWidth*scale, glyphHeight*scale)
+}
+
+// drawText draws the given text onto the image, with the top-left
+// corner at the given point, scaling each font pixel up to scale*scale
+// image pixels. Unknown characters are drawn as question marks.
+func drawText(
+	dst draw.Image, at image.Point, text string, c color.Color, scale int,
+) {
+	for i, r := range []rune(strings.ToUpper(text)) {
+		g, ok := glyphs[r]
+		if !ok {
+			g = glyphs['?']
+		}
+		x0 := at.X + i*glyphWidth*scale
+		for gy, row := range g {
+			for gx := 0; gx < 3; gx++ {
+				if row&(4>>gx) == 0 {
+					continue
+				}
+				px := image.Rect(0, 0, scale, scale).Add(image.Pt(
+					x0+gx*scale, at.Y+gy*scale,
+				))
+				draw.Draw(dst, px, image.NewUniform(c), image.Point{}, draw.Src)
+			}
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// Format is the name of a tile data format, as given on the command
+// line. It can be any of the names listed in the formats table, or a
+// custom bit layout (see layoutPrefix).
+type Format string
+
+// formatInfo describes a tile data format that the CLI knows about.
+type formatInfo struct {
+	Names []string
+	Help  string
+	Codec func(bpp tileconv.BitDepth) tileconv.Codec
+
+	// Depths lists the supported bit depths; nil means all of them.
+	Depths []tileconv.BitDepth
+}
+
+// Supports returns true if the format supports the given bit depth.
+func (f *formatInfo) Supports(bpp tileconv.BitDepth) bool {
+	if f.Depths == nil {
+		return bpp >= tileconv.BD1 && bpp <= tileconv.BD8
+	}
+	for _, d := range f.Depths {
+		if d == bpp {
+			return true
+		}
+	}
+	return false
+}
+
+// formats is the list of tile data formats that the CLI knows about.
+//
+// The first name of each format is the short name, used in labels.
+var formats = []formatInfo{
+	{
+		Names: []string{"p", "packed"},
+		Help:  "packed-pixel",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.Packed{BitDepth: bpp}
+		},
+	},
+	{
+		Names: []string{"tp", "tileplanar"},
+		Help:  "planar, per tile",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.TilePlanar{BitDepth: bpp}
+		},
+	},
+	{
+		Names: []string{"rp", "rowplanar"},
+		Help:  "planar, per row",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.RowPlanar{BitDepth: bpp}
+		},
+	},
+	{
+		Names: []string{"trpp", "tilerowpairplanar"},
+		Help:  "planar, pairs per row, rest per tile",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.TileRowPairPlanar{BitDepth: bpp}
+		},
+	},
+	{
+		Names: []string{"ngp", "neogeopocket"},
+		Help:  "packed, 16-bit little-endian words, high pixel first",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.PackedWord{BitDepth: bpp}
+		},
+	},
+	{
+		Names: []string{"vb", "virtualboy"},
+		Help:  "packed, 16-bit little-endian words, low pixel first",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.PackedWord{BitDepth: bpp, LowFirst: true}
+		},
+	},
+	{
+		Names: []string{"ws", "wonderswan"},
+		Help:  "WonderSwan planar (2bpp, or 4bpp Color); same as rp",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.RowPlanar{BitDepth: bpp}
+		},
+		Depths: []tileconv.BitDepth{tileconv.BD2, tileconv.BD4},
+	},
+	{
+		Names: []string{"wsp", "wonderswanpacked"},
+		Help:  "WonderSwan Color packed (4bpp); same as p",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.Packed{BitDepth: bpp}
+		},
+		Depths: []tileconv.BitDepth{tileconv.BD4},
+	},
+	{
+		Names: []string{"wp", "wordplanar"},
+		Help:  "planar, 16-pixel words per row (Atari ST), 16x16 tiles",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.WordPlanar{BitDepth: bpp}
+		},
+	},
+	{
+		Names: []string{"pces", "pcesprite"},
+		Help:  "PC Engine sprites, 16x16 (4bpp)",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.PCESprite{}
+		},
+		Depths: []tileconv.BitDepth{tileconv.BD4},
+	},
+	{
+		Names: []string{"neogeo", "neogeosprite"},
+		Help:  "Neo Geo sprites, 16x16, merged C-ROM pair (4bpp)",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.NeoGeoSprite{}
+		},
+		Depths: []tileconv.BitDepth{tileconv.BD4},
+	},
+	{
+		Names: []string{"c64mc", "c64multicolor"},
+		Help:  "C64 multicolor characters, wide pixels (2bpp)",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.C64Multicolor{WidePixels: true}
+		},
+		Depths: []tileconv.BitDepth{tileconv.BD2},
+	},
+	{
+		Names: []string{"c64spr", "c64sprite"},
+		Help:  "C64 sprites, hi-res (1bpp) or multicolor (2bpp)",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			return tileconv.C64Sprite{
+				Multicolor: bpp == tileconv.BD2,
+				WidePixels: true,
+			}
+		},
+		Depths: []tileconv.BitDepth{tileconv.BD1, tileconv.BD2},
+	},
+	{
+		Names: []string{"cpc", "amstradcpc"},
+		Help:  "Amstrad CPC sprites, mode 0, 1 or 2 (4, 2 or 1bpp)",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			modes := map[tileconv.BitDepth]int{
+				tileconv.BD4: 0, tileconv.BD2: 1, tileconv.BD1: 2,
+			}
+			return tileconv.CPCSprite{Mode: modes[bpp]}
+		},
+		Depths: []tileconv.BitDepth{
+			tileconv.BD1, tileconv.BD2, tileconv.BD4,
+		},
+	},
+}
+
+// layoutPrefix starts the name of a custom tile data format, which is
+// followed by a bit layout description as accepted by ParseBitLayout,
+// or by "@" and the name of a file that contains such a description.
+const layoutPrefix = "layout:"
+
+const layoutHelp = `Custom formats can be given as layout:SPEC or layout:@FILE, where SPEC
+is a bit layout with settings separated by spaces or semicolons:
+    order=A,B,... : storage order of the x, y, p (plane) and g (plane
+                    group) axes, outermost first; "-" reverses an axis
+    group=N       : planes per group, when using the g axis
+    tile=WxH      : tile size (default 8x8)
+    word=N        : bytes per word (default 1)
+    endian=E      : byte order of words, little (default) or big
+    bits=B        : fill words from the msb (default) or lsb
+    bpp=N         : restrict the format to a single bit depth
+E.g. rp is layout:order=y,p,x and trpp is layout:order=g,y,p,x;group=2`
+
+// findFormat returns the format with the given name, or nil if unknown.
+func findFormat(name string) *formatInfo {
+	fi, _ := lookupFormat(name)
+	return fi
+}
+
+// lookupFormat returns the format with the given name, or an error if
+// it is unknown or is an invalid custom format.
+//
+// The name can end with modifiers (see modifiersHelp), which are split
+// off at the first "+".
+func lookupFormat(name string) (*formatInfo, error) {
+	if base, mods, ok := strings.Cut(name, "+"); ok {
+		fi, err := lookupFormat(base)
+		if err != nil {
+			return nil, err
+		}
+		return withModifiers(fi, name, strings.Split(mods, "+"))
+	}
+
+	for i := range formats {
+		for _, n := range formats[i].Names {
+			if n == name {
+				return &formats[i], nil
+			}
+		}
+	}
+
+	if !strings.HasPrefix(name, layoutPrefix) {
+		return nil, fmt.Errorf("unknown tile format %q", name)
+	}
+	spec := strings.TrimPrefix(name, layoutPrefix)
+	if strings.HasPrefix(spec, "@") {
+		data, err := os.ReadFile(spec[1:])
+		if err != nil {
+			return nil, err
+		}
+		spec = string(data)
+	}
+	layout, err := tileconv.ParseBitLayout(spec)
+	if err != nil {
+		return nil, fmt.Errorf("tile format %q: %w", name, err)
+	}
+
+	fi := &formatInfo{
+		Names: []string{name},
+		Help:  "custom bit layout",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			c := layout
+			c.BitDepth = bpp
+			return c
+		},
+	}
+	if layout.BitDepth != 0 {
+		fi.Depths = []tileconv.BitDepth{layout.BitDepth}
+	}
+	return fi, nil
+}
+
+// formatsHelp returns the help text that lists the known formats.
+func formatsHelp() string {
+	width := 0
+	for _, f := range formats {
+		if w := len(strings.Join(f.Names, ", ")); w > width {
+			width = w
+		}
+	}
+	var b strings.Builder
+	b.WriteString("Tile data formats:")
+	for _, f := range formats {
+		fmt.Fprintf(
+			&b, "\n    %-*s : %s", width, strings.Join(f.Names, ", "), f.Help,
+		)
+	}
+	b.WriteString("\n\n" + layoutHelp)
+	b.WriteString("\n\n" + modifiersHelp)
+	return b.String()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *Format) UnmarshalText(text []byte) error {
+	if _, err := lookupFormat(string(text)); err != nil {
+		return err
+	}
+	*f = Format(text)
+	return nil
+}
+
+// ShortName returns the short name of the format, for use in labels.
+func (f Format) ShortName() string {
+	if fi := findFormat(string(f)); fi != nil {
+		return fi.Names[0]
+	}
+	return string(f)
+}
+
+// Codec returns the codec for this format, using the given bit depth.
+func (f Format) Codec(bpp tileconv.BitDepth) (tileconv.Codec, error) {
+	fi, err := lookupFormat(string(f))
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Supports(bpp) {
+		return nil, fmt.Errorf("format %s does not support %vbpp", f, bpp)
+	}
+	return fi.Codec(bpp), nil
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// This file implements loading and saving Amiga IFF ILBM images, using
+// tileconv.ImagePlanar for the interleaved bit planes of the body.
+
+func init() {
+	image.RegisterFormat(
+		"ilbm", "FORM????ILBM", decodeILBM, decodeILBMConfig,
+	)
+}
+
+// ilbmHeader is the contents of the BMHD chunk.
+type ilbmHeader struct {
+	Width, Height    uint16
+	X, Y             int16
+	Planes           uint8
+	Masking          uint8
+	Compression      uint8
+	Pad              uint8
+	TransparentColor uint16
+	XAspect, YAspect uint8
+	PageWidth        int16
+	PageHeight       int16
+}
+
+const (
+	ilbmMaskHasMask = 1
+	ilbmCompressRLE = 1
+)
+
+// ilbmChunks reads the chunks of an ILBM file, calling fn for each of
+// them until fn returns done or an error.
+func ilbmChunks(
+	r io.Reader, fn func(id string, data []byte) (done bool, err error),
+) error {
+	var hdr [12]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return err
+	}
+	if string(hdr[0:4]) != "FORM" || string(hdr[8:12]) != "ILBM" {
+		return errors.New("ilbm: not an IFF ILBM file")
+	}
+	lr := &io.LimitedReader{
+		R: r, N: int64(binary.BigEndian.Uint32(hdr[4:8])) - 4,
+	}
+
+	for {
+		var ch [8]byte
+		if _, err := io.ReadFull(lr, ch[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// Check the size before allocating, so a bad size cannot make us
+		// allocate far more memory than the file could contain.
+		size := int64(binary.BigEndian.Uint32(ch[4:8]))
+		if size+size&1 > lr.N {
+			return fmt.Errorf(
+				"ilbm: %q chunk size %v is beyond the end of the FORM",
+				ch[0:4], size,
+			)
+		}
+		data := make([]byte, size+size&1)
+		if _, err := io.ReadFull(lr, data); err != nil {
+			return err
+		}
+		if done, err := fn(string(ch[0:4]), data[:size]); done || err != nil {
+			return err
+		}
+	}
+}
+
+func decodeILBMConfig(r io.Reader) (image.Config, error) {
+	var cfg image.Config
+	var hdr *ilbmHeader
+	err := ilbmChunks(r, func(id string, data []byte) (bool, error) {
+		switch id {
+		case "BMHD":
+			h, err := parseILBMHeader(data)
+			hdr = h
+			return false, err
+		case "CMAP":
+			cfg.ColorModel = parseILBMPalette(data)
+		case "BODY":
+			return true, nil
+		}
+		return false, nil
+	})
+	if err == nil && hdr == nil {
+		err = errors.New("ilbm: missing BMHD chunk")
+	}
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Width, cfg.Height = int(hdr.Width), int(hdr.Height)
+	if cfg.ColorModel == nil {
+		cfg.ColorModel = makePalette(tileconv.BitDepth(hdr.Planes))
+	}
+	return cfg, nil
+}
+
+func decodeILBM(r io.Reader) (image.Image, error) {
+	var hdr *ilbmHeader
+	var pal color.Palette
+	var img *image.Paletted
+	err := ilbmChunks(r, func(id string, data []byte) (bool, error) {
+		switch id {
+		case "BMHD":
+			h, err := parseILBMHeader(data)
+			hdr = h
+			return false, err
+		case "CMAP":
+			pal = parseILBMPalette(data)
+		case "BODY":
+			if hdr == nil {
+				return false, errors.New("ilbm: BODY before BMHD")
+			}
+			i, err := decodeILBMBody(hdr, pal, data)
+			img = i
+			return true, err
+		}
+		return false, nil
+	})
+	if err == nil && img == nil {
+		err = errors.New("ilbm: missing BODY chunk")
+	}
+	return img, err
+}
+
+func parseILBMHeader(data []byte) (*ilbmHeader, error) {
+	var hdr ilbmHeader
+	err := binary.Read(bytes.NewReader(data), binary.BigEndian, &hdr)
+	if err != nil {
+		return nil, fmt.Errorf("ilbm: bad BMHD chunk: %w", err)
+	}
+	if hdr.Planes < 1 || hdr.Planes > 8 {
+		return nil, fmt.Errorf("ilbm: unsupported planes: %v", hdr.Planes)
+	}
+	if hdr.Compression > ilbmCompressRLE {
+		return nil, fmt.Errorf(
+			"ilbm: unsupported compression: %v", hdr.Compression,
+		)
+	}
+	return &hdr, nil
+}
+
+func parseILBMPalette(data []byte) color.Palette {
+	pal := make(color.Palette, len(data)/3)
+	for i := range pal {
+		pal[i] = color.RGBA{data[i*3], data[i*3+1], data[i*3+2], 0xFF}
+	}
+	return pal
+}
+
+func decodeILBMBody(
+	hdr *ilbmHeader, pal color.Palette, data []byte,
+) (*image.Paletted, error) {
+	bd := tileconv.BitDepth(hdr.Planes)
+	w, h := int(hdr.Width), int(hdr.Height)
+
+	// Make sure that all the pixels have a color in the palette.
+	if len(pal) < bd.Colors() {
+		full := makePalette(bd)
+		copy(full, pal)
+		pal = full
+	}
+
+	// The mask plane (if any) is stored like an extra bit plane, so we
+	// decode it as one, and then ignore its bit in the result.
+	planes := bd
+	if hdr.Masking == ilbmMaskHasMask {
+		planes++
+	}
+	codec := tileconv.ImagePlanar{
+		BitDepth: planes, Interleaved: true, RowStride: (w + 15) / 16 * 2,
+	}
+	size := codec.Size(w, h)
+
+	if hdr.Compression == ilbmCompressRLE {
+		var err error
+		if data, err = unpackByteRun1(data, size); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) < size {
+		return nil, errors.New("ilbm: BODY chunk is too short")
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, w, h), pal)
+	codec.Decode(data, img, img.Rect)
+	if planes != bd {
+		for i := range img.Pix {
+			img.Pix[i] &= bd.ColorMask()
+		}
+	}
+	return img, nil
+}
+
+// unpackByteRun1 decompresses data compressed with the ByteRun1 (aka.
+// PackBits) algorithm, stopping when size bytes have been produced.
+func unpackByteRun1(data []byte, size int) ([]byte, error) {
+	// Each 2 bytes of data produce at most 128 bytes, so check that the
+	// size is possible before allocating, as it comes from the header.
+	if size > len(data)*64 {
+		return nil, errors.New("ilbm: compressed data is too short")
+	}
+	out := make([]byte, 0, size)
+	for len(out) < size {
+		if len(data) < 2 {
+			return nil, errors.New("ilbm: truncated compressed data")
+		}
+		n := int(int8(data[0]))
+		switch {
+		case n >= 0:
+			if len(data) < n+2 {
+				return nil, errors.New("ilbm: truncated compressed data")
+			}
+			out = append(out, data[1:n+2]...)
+			data = data[n+2:]
+		case n != -128:
+			for i := 0; i < 1-n; i++ {
+				out = append(out, data[1])
+			}
+			data = data[2:]
+		default:
+			data = data[1:]
+		}
+	}
+	return out, nil
+}
+
+// packByteRun1 compresses a single row with the ByteRun1 algorithm.
+func packByteRun1(dst, row []byte) []byte {
+	for len(row) > 0 {
+		// Find the length of the run at the start of the row.
+		run := 1
+		for run < len(row) && run < 128 && row[run] == row[0] {
+			run++
+		}
+		if run > 2 {
+			dst = append(dst, byte(1-run), row[0])
+			row = row[run:]
+			continue
+		}
+
+		// Find the length of the literal data, up to the next run.
+		lit := 1
+		for lit < len(row) && lit < 128 {
+			if lit+2 < len(row) &&
+				row[lit] == row[lit+1] && row[lit] == row[lit+2] {
+				break
+			}
+			lit++
+		}
+		dst = append(dst, byte(lit-1))
+		dst = append(dst, row[:lit]...)
+		row = row[lit:]
+	}
+	return dst
+}
+
+// writeILBM writes the image as an IFF ILBM file, with a compressed
+// body and a bit depth that fits its palette.
+func writeILBM(out io.Writer, img *image.Paletted) error {
+	bd := tileconv.BD1
+	for bd < tileconv.BD8 && bd.Colors() < len(img.Palette) {
+		bd++
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > 0xFFFF || h > 0xFFFF {
+		return errors.New("ilbm: image is too large")
+	}
+
+	hdr := ilbmHeader{
+		Width: uint16(w), Height: uint16(h),
+		Planes: uint8(bd), Compression: ilbmCompressRLE,
+		XAspect: 1, YAspect: 1,
+		PageWidth: int16(w), PageHeight: int16(h),
+	}
+	var bmhd bytes.Buffer
+	if err := binary.Write(&bmhd, binary.BigEndian, &hdr); err != nil {
+		return err
+	}
+
+	cmap := make([]byte, 0, bd.Colors()*3)
+	for i := 0; i < bd.Colors(); i++ {
+		var c color.RGBA
+		if i < len(img.Palette) {
+			c = color.RGBAModel.Convert(img.Palette[i]).(color.RGBA)
+		}
+		cmap = append(cmap, c.R, c.G, c.B)
+	}
+
+	codec := tileconv.ImagePlanar{
+		BitDepth: bd, Interleaved: true, RowStride: (w + 15) / 16 * 2,
+	}
+	planes := make([]byte, codec.Size(w, h))
+	codec.Encode(img, b, planes)
+
+	// Each row of each plane is compressed separately.
+	var body []byte
+	for i := 0; i < len(planes); i += codec.RowStride {
+		body = packByteRun1(body, planes[i:i+codec.RowStride])
+	}
+
+	chunks := []struct {
+		id   string
+		data []byte
+	}{
+		{"BMHD", bmhd.Bytes()},
+		{"CMAP", cmap},
+		{"BODY", body},
+	}
+
+	size := 4
+	for _, c := range chunks {
+		size += 8 + len(c.data) + len(c.data)&1
+	}
+
+	bw := bufio.NewWriter(out)
+	bw.WriteString("FORM")
+	binary.Write(bw, binary.BigEndian, uint32(size))
+	bw.WriteString("ILBM")
+	for _, c := range chunks {
+		bw.WriteString(c.id)
+		binary.Write(bw, binary.BigEndian, uint32(len(c.data)))
+		bw.Write(c.data)
+		if len(c.data)&1 != 0 {
+			bw.WriteByte(0)
+		}
+	}
+	return bw.Flush()
+}
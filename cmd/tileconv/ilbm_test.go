@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"testing"
+)
+
+func TestDecodeILBM_HugeHeader(t *testing.T) {
+	// A tiny compressed BODY with a header that claims a huge image, with
+	// 8 planes and a mask, should fail without allocating for the image.
+	hdr := ilbmHeader{
+		Width: 65535, Height: 65535, Planes: 8,
+		Masking: ilbmMaskHasMask, Compression: ilbmCompressRLE,
+	}
+	var bmhd bytes.Buffer
+	if err := binary.Write(&bmhd, binary.BigEndian, hdr); err != nil {
+		t.Fatal(err)
+	}
+	body := []byte{0x81, 0x00}
+
+	var form bytes.Buffer
+	chunk := func(id string, data []byte) {
+		form.WriteString(id)
+		binary.Write(&form, binary.BigEndian, uint32(len(data)))
+		form.Write(data)
+	}
+	form.WriteString("ILBM")
+	chunk("BMHD", bmhd.Bytes())
+	chunk("BODY", body)
+
+	var file bytes.Buffer
+	file.WriteString("FORM")
+	binary.Write(&file, binary.BigEndian, uint32(form.Len()))
+	file.Write(form.Bytes())
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := decodeILBM(&file)
+	runtime.ReadMemStats(&after)
+	if err == nil {
+		t.Error("no error for a BODY that is too short")
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("allocated %v bytes", n)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type KoalaArgs struct {
+	Image  string `arg:"positional,required" help:"image file"`
+	Koala  string `arg:"positional,required" help:"Koala Painter file"`
+	Decode bool   `arg:"-d" help:"decode the Koala file into the image"`
+	Wide   bool   `arg:"-w" help:"use double-wide pixels (320x200 image)"`
+	Bg     uint8  `arg:"--bg" help:"background color index (0-15)"`
+}
+
+func (KoalaArgs) Description() string {
+	return "Converts between a C64-paletted image (160x200, or 320x200 " +
+		"with --wide)\nand a Koala Painter multicolor bitmap file. By " +
+		"default, this encodes\nthe image into the Koala file."
+}
+
+func runKoalaCommand(argv []string) error {
+	var args KoalaArgs
+	mustParse("koala", &args, argv)
+
+	if args.Decode {
+		if err := checkImageFormat(args.Image); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(args.Koala)
+		if err != nil {
+			return err
+		}
+		img, err := tileconv.DecodeKoala(data, args.Wide)
+		if err != nil {
+			return err
+		}
+		return saveImage(args.Image, img)
+	}
+
+	img, err := loadImage(args.Image)
+	if err != nil {
+		return err
+	}
+	data, err := tileconv.EncodeKoala(img, args.Bg, args.Wide)
+	if err != nil {
+		return withClashDetails(err)
+	}
+	return os.WriteFile(args.Koala, data, 0o666)
+}
+
+// withClashDetails prints the details of each color clash to stderr if
+// the error is a *tileconv.ColorClashError, and then returns the error.
+func withClashDetails(err error) error {
+	var ce *tileconv.ColorClashError
+	if errors.As(err, &ce) {
+		for _, c := range ce.Clashes {
+			fmt.Fprintln(os.Stderr, "Clash:", c)
+		}
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"image"
+	"os"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type Mode7Args struct {
+	VRAM   string `arg:"positional,required" help:"interleaved VRAM file"`
+	Tiles  string `arg:"-t,required" help:"tile set image file"`
+	Map    string `arg:"-m" help:"tile map file (128x128 bytes)"`
+	Decode bool   `arg:"-d" help:"decode VRAM into tiles and map"`
+}
+
+func (Mode7Args) Description() string {
+	return "Converts between an SNES Mode 7 interleaved VRAM image and " +
+		"its tile set\n(an image of up to 256 8bpp tiles) and tile map. " +
+		"By default, this\nencodes the tile set and map into the VRAM file."
+}
+
+func runMode7Command(argv []string) error {
+	var args Mode7Args
+	mustParse("mode7", &args, argv)
+	if args.Decode {
+		return runMode7Decode(args)
+	}
+	return runMode7Encode(args)
+}
+
+func runMode7Encode(args Mode7Args) error {
+	tiles, err := loadImage(args.Tiles)
+	if err != nil {
+		return err
+	}
+
+	var tileMap []byte
+	if args.Map != "" {
+		if tileMap, err = os.ReadFile(args.Map); err != nil {
+			return err
+		}
+	}
+
+	vram, err := tileconv.EncodeMode7(tiles, tileMap)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(args.VRAM, vram, 0o666)
+}
+
+func runMode7Decode(args Mode7Args) error {
+	if err := checkImageFormat(args.Tiles); err != nil {
+		return err
+	}
+
+	vram, err := os.ReadFile(args.VRAM)
+	if err != nil {
+		return err
+	}
+
+	// 256 tiles, 16 tiles per row.
+	tiles := image.NewPaletted(
+		image.Rect(0, 0, 16*8, 16*8), makePalette(tileconv.BD8),
+	)
+	tileMap, err := tileconv.DecodeMode7(vram, tiles)
+	if err != nil {
+		return err
+	}
+
+	if args.Map != "" {
+		if err := os.WriteFile(args.Map, tileMap, 0o666); err != nil {
+			return err
+		}
+	}
+
+	return saveImage(args.Tiles, tiles)
+}
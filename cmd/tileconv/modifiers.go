@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/edorfaus/tileconv"
+)
+
+const modifiersHelp = `Formats can be modified by adding +MOD to the name (repeatedly), where
+MOD is one of these, which are applied in the given order:
+    swap[=N]      : reverse the bytes in each N-byte word (default 2)
+    revbits       : reverse the bits in each byte
+    planes=A,B... : store color bit A in plane 0, bit B in plane 1, etc.
+    xor=HEX       : XOR the data of each tile with the (repeated) key
+E.g. rp+planes=1,0+xor=FF swaps the planes of rp, then inverts all bits`
+
+// modifier wraps a codec to modify how it encodes the tiles.
+type modifier func(c tileconv.Codec) tileconv.Codec
+
+// withModifiers returns a format with the given name, that is the given
+// format with the given modifiers applied to it.
+func withModifiers(
+	fi *formatInfo, name string, mods []string,
+) (*formatInfo, error) {
+	var wraps []modifier
+	for _, m := range mods {
+		w, err := parseModifier(m)
+		if err != nil {
+			return nil, fmt.Errorf("tile format %q: %w", name, err)
+		}
+		wraps = append(wraps, w)
+	}
+
+	base := fi.Codec
+	return &formatInfo{
+		Names: []string{name},
+		Help:  fi.Help + ", modified",
+		Codec: func(bpp tileconv.BitDepth) tileconv.Codec {
+			c := base(bpp)
+			for _, w := range wraps {
+				c = w(c)
+			}
+			return c
+		},
+		Depths: fi.Depths,
+	}, nil
+}
+
+func parseModifier(m string) (modifier, error) {
+	key, value, hasValue := strings.Cut(m, "=")
+	switch {
+	case key == "swap":
+		size := 2
+		if hasValue {
+			var err error
+			size, err = strconv.Atoi(value)
+			if err != nil || size < 1 {
+				return nil, fmt.Errorf("invalid word size: %q", value)
+			}
+		}
+		return func(c tileconv.Codec) tileconv.Codec {
+			return tileconv.ByteSwap{Codec: c, WordSize: size}
+		}, nil
+
+	case key == "revbits" && !hasValue:
+		return func(c tileconv.Codec) tileconv.Codec {
+			return tileconv.ReverseBits{Codec: c}
+		}, nil
+
+	case key == "planes" && hasValue:
+		var planes []int
+		for _, s := range strings.Split(value, ",") {
+			bit, err := strconv.Atoi(s)
+			if err != nil || bit < 0 || bit > 7 {
+				return nil, fmt.Errorf("invalid color bit: %q", s)
+			}
+			planes = append(planes, bit)
+		}
+		return func(c tileconv.Codec) tileconv.Codec {
+			return tileconv.PlaneOrder{Codec: c, Planes: planes}
+		}, nil
+
+	case key == "xor" && hasValue:
+		key, err := hex.DecodeString(value)
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("invalid XOR key: %q", value)
+		}
+		return func(c tileconv.Codec) tileconv.Codec {
+			return tileconv.XOR{Codec: c, Key: key}
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid modifier: %q", m)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type NeoGeoArgs struct {
+	Image  string `arg:"positional,required" help:"image file"`
+	ROM1   string `arg:"positional,required" help:"first C-ROM (C1, C3, ...)"`
+	ROM2   string `arg:"positional,required" help:"second C-ROM (C2, C4, ...)"`
+	Decode bool   `arg:"-d" help:"decode the C-ROM pair into the image"`
+}
+
+func (NeoGeoArgs) Description() string {
+	return "Converts between an image of 16x16 4bpp sprite tiles and a " +
+		"pair of Neo Geo\nC-ROM files. By default, this encodes the image " +
+		"and splits the data\nacross the two ROM files."
+}
+
+func runNeoGeoCommand(argv []string) error {
+	var args NeoGeoArgs
+	mustParse("neogeo", &args, argv)
+
+	codec := tileconv.NeoGeoSprite{}
+
+	if args.Decode {
+		if err := checkImageFormat(args.Image); err != nil {
+			return err
+		}
+		c1, err := os.ReadFile(args.ROM1)
+		if err != nil {
+			return err
+		}
+		c2, err := os.ReadFile(args.ROM2)
+		if err != nil {
+			return err
+		}
+		data, err := tileconv.MergeNeoGeoCROM(c1, c2)
+		if err != nil {
+			return err
+		}
+		if len(data)%codec.Size() != 0 {
+			return fmt.Errorf("input is not a whole number of tiles")
+		}
+		img := decodeSheet(data, codec, tileconv.BD4, 16)
+		return saveImage(args.Image, img)
+	}
+
+	img, err := loadImage(args.Image)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tileconv.Encode(img, &buf, codec); err != nil {
+		return err
+	}
+	c1, c2 := tileconv.SplitNeoGeoCROM(buf.Bytes())
+	if err := os.WriteFile(args.ROM1, c1, 0o666); err != nil {
+		return err
+	}
+	return os.WriteFile(args.ROM2, c2, 0o666)
+}
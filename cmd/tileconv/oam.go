@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type OAMArgs struct {
+	Image  string `arg:"positional,required" help:"frame image file"`
+	Output string `arg:"positional,required" help:"output tile data file"`
+	OAM    string `arg:"positional,required" help:"output sprite list (JSON)"`
+
+	Format Format            `arg:"-f,required" help:"tile data format"`
+	Bpp    tileconv.BitDepth `arg:"-b,required" help:"bits per pixel; 1-8"`
+	HW     string            `arg:"--hw" default:"nes8" help:"sprite hardware"`
+	Shapes string            `arg:"-s" help:"shapes in tiles, e.g. 1x1,2x2"`
+}
+
+func (OAMArgs) Description() string {
+	return "Covers the pixels of a frame that are not color 0 (transparent) " +
+		"with as few\nhardware sprites as it can, encodes their tiles into " +
+		"the output file, and\nwrites the list of sprites, with their " +
+		"positions, tile indexes and flips."
+}
+
+func (OAMArgs) Epilogue() string {
+	return `Sprite hardware:
+    nes8  : NES (or Master System) 8x8 sprites
+    nes16 : NES 8x16 sprites
+    snesN : SNES sprites, with object size setting N (0-7, from OBSEL),
+            e.g. snes0 for 8x8 and 16x16; tiles use a 16-tile-wide grid
+    gba   : Game Boy Advance sprites, 1D tile mapping
+    md    : Mega Drive sprites, 1x1 to 4x4 tiles, stored column by column
+Other hardware can be given as --shapes, which overrides the shapes of
+--hw; the tiles of each sprite are then stored one after the other.
+
+Sprite list format (x and y are relative to the top-left of the frame):
+    {
+        "tileWidth": 8, "tileHeight": 8, "tiles": 3,
+        "sprites": [
+            {"x": -2, "y": 0, "cols": 1, "rows": 2, "tile": 0,
+             "hflip": false, "vflip": false},
+            ...
+        ]
+    }
+
+` + formatsHelp()
+}
+
+// spriteHardware is the sprite hardware that can be given with --hw.
+var spriteHardware = map[string]tileconv.SpriteHardware{
+	"nes8":  tileconv.NES8x8Sprites,
+	"nes16": tileconv.NES8x16Sprites,
+	"gba":   tileconv.GBASprites,
+	"md":    tileconv.MegaDriveSprites,
+}
+
+func init() {
+	for i, hw := range tileconv.SNESSprites {
+		spriteHardware[fmt.Sprintf("snes%d", i)] = hw
+	}
+}
+
+// OAMList is the contents of a sprite list file.
+type OAMList struct {
+	TileWidth  int         `json:"tileWidth"`
+	TileHeight int         `json:"tileHeight"`
+	Tiles      int         `json:"tiles"`
+	Sprites    []OAMSprite `json:"sprites"`
+}
+
+// OAMSprite describes a single hardware sprite in a sprite list file.
+type OAMSprite struct {
+	X     int  `json:"x"`
+	Y     int  `json:"y"`
+	Cols  int  `json:"cols"`
+	Rows  int  `json:"rows"`
+	Tile  int  `json:"tile"`
+	HFlip bool `json:"hflip"`
+	VFlip bool `json:"vflip"`
+}
+
+func runOAMCommand(argv []string) error {
+	var args OAMArgs
+	mustParse("oam", &args, argv)
+
+	hw, ok := spriteHardware[args.HW]
+	if !ok {
+		return fmt.Errorf("unknown sprite hardware: %q", args.HW)
+	}
+	if args.Shapes != "" {
+		hw.Shapes = nil
+		hw.ColumnMajor = false
+		hw.TileStride = 0
+		for _, s := range strings.Split(args.Shapes, ",") {
+			cols, rows, err := parseSize(s)
+			if err != nil {
+				return fmt.Errorf("invalid sprite shape: %w", err)
+			}
+			hw.Shapes = append(hw.Shapes, tileconv.SpriteShape{
+				Cols: cols, Rows: rows,
+			})
+		}
+	}
+
+	codec, err := args.Format.Codec(args.Bpp)
+	if err != nil {
+		return err
+	}
+
+	img, err := loadImage(args.Image)
+	if err != nil {
+		return err
+	}
+
+	entries, tiles, err := tileconv.BuildOAM(img, hw, codec)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(args.Output, tiles, 0o666); err != nil {
+		return err
+	}
+
+	list := OAMList{Tiles: len(tiles) / codec.Size()}
+	list.TileWidth, list.TileHeight = tileconv.TileSize(codec)
+	list.Sprites = make([]OAMSprite, 0, len(entries))
+	for _, e := range entries {
+		list.Sprites = append(list.Sprites, OAMSprite{
+			X:     e.X,
+			Y:     e.Y,
+			Cols:  e.Shape.Cols,
+			Rows:  e.Shape.Rows,
+			Tile:  e.Tile,
+			HFlip: e.HFlip,
+			VFlip: e.VFlip,
+		})
+	}
+	js, err := json.MarshalIndent(list, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(args.OAM, append(js, '\n'), 0o666)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type PlanesArgs struct {
+	Image  string   `arg:"positional,required" help:"image file"`
+	Planes []string `arg:"positional,required" help:"bit plane files, lowest bit first"`
+	Decode bool     `arg:"-d" help:"merge the bit plane files into the image"`
+	Format Format   `arg:"-f" default:"rp" help:"tile data format of the bit plane files"`
+	Cols   int      `default:"16" help:"max tiles per row when merging"`
+}
+
+func (PlanesArgs) Description() string {
+	return "Splits the color indexes of an image into separate bit " +
+		"planes, each of which\nis written as 1bpp tile data, or merges " +
+		"such bit planes into an image. A\nbit plane file with an image " +
+		"extension (e.g. .png) is written or read as an\nimage instead, " +
+		"and - can be given to skip a bit plane (zeroing it when\nmerging)."
+}
+
+func (PlanesArgs) Epilogue() string {
+	return formatsHelp()
+}
+
+func runPlanesCommand(argv []string) error {
+	var args PlanesArgs
+	mustParse("planes", &args, argv)
+
+	if len(args.Planes) > 8 {
+		return fmt.Errorf("too many bit planes: %v", len(args.Planes))
+	}
+	codec, err := args.Format.Codec(tileconv.BD1)
+	if err != nil {
+		return err
+	}
+
+	if args.Decode {
+		return mergePlanes(args, codec)
+	}
+
+	img, err := loadImage(args.Image)
+	if err != nil {
+		return err
+	}
+	for i, p := range tileconv.SplitPlanes(img, len(args.Planes)) {
+		fn := args.Planes[i]
+		if fn == "-" {
+			continue
+		}
+		if checkImageFormat(fn) == nil {
+			err = saveImage(fn, p)
+		} else {
+			var buf bytes.Buffer
+			if err = tileconv.Encode(p, &buf, codec); err == nil {
+				err = os.WriteFile(fn, buf.Bytes(), 0o666)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergePlanes(args PlanesArgs, codec tileconv.Codec) error {
+	if err := checkImageFormat(args.Image); err != nil {
+		return err
+	}
+	if args.Cols < 1 {
+		return fmt.Errorf("invalid number of columns: %v", args.Cols)
+	}
+
+	planes := make([]image.PalettedImage, len(args.Planes))
+	for i, fn := range args.Planes {
+		if fn == "-" {
+			continue
+		}
+		if checkImageFormat(fn) == nil {
+			img, err := loadImage(fn)
+			if err != nil {
+				return err
+			}
+			planes[i] = img
+			continue
+		}
+		data, err := os.ReadFile(fn)
+		if err != nil {
+			return err
+		}
+		if len(data)%codec.Size() != 0 {
+			return fmt.Errorf("%s is not a whole number of tiles", fn)
+		}
+		planes[i] = decodeSheet(data, codec, tileconv.BD1, args.Cols)
+	}
+
+	bpp := tileconv.BitDepth(len(planes))
+	img, err := tileconv.MergePlanes(planes, makePalette(bpp))
+	if err != nil {
+		return err
+	}
+	return saveImage(args.Image, img)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/edorfaus/tileconv"
+)
+
+const remapHelp = `The --remap option changes the color indexes while converting, as:
+    compact       : use the lowest indexes for the used colors (encode)
+    +N or -N      : add N to, or subtract N from, each index
+    A:B,C:D,...   : change index A to B, C to D, etc., keeping the rest
+E.g. -b 4 --remap +12 moves 2bpp tiles to colors 12-15 of a 4bpp image.
+It is an error if a remap would lose colors, i.e. if two used colors end
+up the same, or a used color ends up out of range for the bit depth.`
+
+// parseRemap parses a color index remap as given on the command line
+// (see remapHelp), using the image to find the used colors for compact.
+// The image is nil when decoding, where compact is not supported.
+func parseRemap(s string, img image.PalettedImage) (tileconv.Remap, error) {
+	switch {
+	case s == "compact":
+		if img == nil {
+			return nil, errors.New("remap compact only works when encoding")
+		}
+		return tileconv.CompactRemap(img), nil
+	case strings.HasPrefix(s, "+"), strings.HasPrefix(s, "-"):
+		offset, err := strconv.Atoi(s)
+		if err != nil || offset < -255 || offset > 255 {
+			return nil, fmt.Errorf("invalid remap offset: %q", s)
+		}
+		return tileconv.OffsetRemap(offset), nil
+	}
+
+	r := tileconv.OffsetRemap(0)
+	for _, item := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(item, ":")
+		a, errA := strconv.ParseUint(from, 10, 8)
+		b, errB := strconv.ParseUint(to, 10, 8)
+		if !ok || errA != nil || errB != nil {
+			return nil, fmt.Errorf("invalid remap entry: %q", item)
+		}
+		r[uint8(a)] = uint8(b)
+	}
+	return r, nil
+}
+
+// remapDepth returns the smallest bit depth (but at least bpp) that has
+// room for what the remap turns the colors of the given depth into.
+func remapDepth(r tileconv.Remap, bpp tileconv.BitDepth) tileconv.BitDepth {
+	bd := bpp
+	for c := 0; c < bpp.Colors(); c++ {
+		to, ok := r[uint8(c)]
+		for ok && int(to) >= bd.Colors() && bd < tileconv.BD8 {
+			bd++
+		}
+	}
+	return bd
+}
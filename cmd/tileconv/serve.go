@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image/color"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type ServeArgs struct {
+	Addr string `arg:"-a" default:"localhost:8080" help:"address to listen on"`
+}
+
+func (ServeArgs) Description() string {
+	return "Starts a local HTTP server that lets you decode and encode " +
+		"tile data\nfrom a web browser, by uploading files to convert."
+}
+
+func runServeCommand(argv []string) error {
+	var args ServeArgs
+	mustParse("serve", &args, argv)
+	return runServe(args)
+}
+
+// maxUploadSize is the max size of a request with uploaded files, which
+// also limits the amount of memory used for them.
+const maxUploadSize = 32 << 20
+
+func runServe(args ServeArgs) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveIndex)
+	mux.HandleFunc("/decode", serveDecode)
+	mux.HandleFunc("/encode", serveEncode)
+
+	log.Printf("Listening on http://%s/", args.Addr)
+	return http.ListenAndServe(args.Addr, mux)
+}
+
+// servePage holds the data used to render the page template.
+type servePage struct {
+	Formats []formatInfo
+	Format  string
+	Bpp     int
+	Cols    int
+	Error   string
+	Image   template.URL
+	Width   int
+}
+
+func newServePage() *servePage {
+	return &servePage{
+		Formats: formats,
+		Format:  formats[0].Names[0],
+		Bpp:     int(tileconv.BD2),
+		Cols:    16,
+	}
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	renderServePage(w, http.StatusOK, newServePage())
+}
+
+// checkServePost replies with an error and returns false if the request
+// is not a POST, and otherwise limits the size of the request body.
+func checkServePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	return true
+}
+
+func serveDecode(w http.ResponseWriter, r *http.Request) {
+	if !checkServePost(w, r) {
+		return
+	}
+	page := newServePage()
+	fail := func(err error) {
+		page.Error = err.Error()
+		renderServePage(w, http.StatusBadRequest, page)
+	}
+
+	codec, bpp, err := parseServeForm(r, page)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	src, err := readFormFile(r, "file")
+	if err != nil {
+		fail(err)
+		return
+	}
+	if len(src) < codec.Size() {
+		fail(fmt.Errorf("input is smaller than a single tile"))
+		return
+	}
+
+	pal, err := servePalette(r, bpp)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	img := decodeSheet(src, codec, bpp, page.Cols)
+	img.Palette = pal
+
+	var buf bytes.Buffer
+	if err := writeImage(&buf, ".png", img); err != nil {
+		fail(err)
+		return
+	}
+	// Show the sheet at double size, since tiles are usually tiny.
+	page.Width = img.Bounds().Dx() * 2
+	page.Image = template.URL(
+		"data:image/png;base64," +
+			base64.StdEncoding.EncodeToString(buf.Bytes()),
+	)
+
+	renderServePage(w, http.StatusOK, page)
+}
+
+func serveEncode(w http.ResponseWriter, r *http.Request) {
+	if !checkServePost(w, r) {
+		return
+	}
+	page := newServePage()
+	fail := func(err error) {
+		page.Error = err.Error()
+		renderServePage(w, http.StatusBadRequest, page)
+	}
+
+	codec, _, err := parseServeForm(r, page)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	f, hdr, err := r.FormFile("file")
+	if err != nil {
+		fail(fmt.Errorf("missing file: %w", err))
+		return
+	}
+	defer f.Close()
+
+	img, err := decodePalettedImage(f, hdr.Filename)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := tileconv.Encode(img, &buf, codec); err != nil {
+		fail(err)
+		return
+	}
+
+	name := strings.TrimSuffix(hdr.Filename, filepath.Ext(hdr.Filename))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", name+".bin"),
+	)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// parseServeForm parses the common form fields into the page, and
+// returns the codec and bit depth that they specify.
+func parseServeForm(
+	r *http.Request, page *servePage,
+) (tileconv.Codec, tileconv.BitDepth, error) {
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		return nil, 0, err
+	}
+
+	var bpp tileconv.BitDepth
+	if err := bpp.UnmarshalText([]byte(r.FormValue("bpp"))); err != nil {
+		return nil, 0, err
+	}
+	page.Bpp = int(bpp)
+
+	var f Format
+	if err := f.UnmarshalText([]byte(r.FormValue("format"))); err != nil {
+		return nil, 0, err
+	}
+	page.Format = f.ShortName()
+
+	if s := r.FormValue("cols"); s != "" {
+		cols, err := strconv.Atoi(s)
+		if err != nil || cols < 1 {
+			return nil, 0, fmt.Errorf("invalid tiles per row: %q", s)
+		}
+		page.Cols = cols
+	}
+
+	codec, err := f.Codec(bpp)
+	return codec, bpp, err
+}
+
+// readFormFile reads the entire contents of the named uploaded file.
+func readFormFile(r *http.Request, name string) ([]byte, error) {
+	f, _, err := r.FormFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("missing %s: %w", name, err)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// servePalette returns the palette to use for decoding. If a palette
+// image was uploaded, its palette is used (padded with gray levels if
+// it is too small), otherwise a gray-level palette is generated.
+func servePalette(
+	r *http.Request, bpp tileconv.BitDepth,
+) (color.Palette, error) {
+	pal := makePalette(bpp)
+
+	f, hdr, err := r.FormFile("palette")
+	if err == http.ErrMissingFile {
+		return pal, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := decodePalettedImage(f, hdr.Filename)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := img.ColorModel().(color.Palette)
+	if !ok {
+		return nil, fmt.Errorf("palette image has no palette")
+	}
+	copy(pal, p)
+	return pal, nil
+}
+
+func renderServePage(w http.ResponseWriter, code int, page *servePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	if err := servePageTemplate.Execute(w, page); err != nil {
+		log.Printf("Error rendering page: %v", err)
+	}
+}
+
+var servePageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>tileconv</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+fieldset { margin-bottom: 1em; }
+label { display: inline-block; margin-right: 1em; }
+.error { color: #b00; font-weight: bold; }
+.sheet { image-rendering: pixelated; border: 1px solid #888; }
+</style>
+</head>
+<body>
+<h1>tileconv</h1>
+{{if .Error}}<p class="error">Error: {{.Error}}</p>{{end}}
+{{define "options"}}
+<label>Format:
+<select name="format">
+{{- range .Formats}}
+<option value="{{index .Names 0}}"{{if eq (index .Names 0) $.Format}} selected{{end}}>{{index .Names 0}} - {{.Help}}</option>
+{{- end}}
+</select></label>
+<label>Bits per pixel:
+<input type="number" name="bpp" min="1" max="8" value="{{.Bpp}}"></label>
+{{end}}
+<form method="post" action="/decode" enctype="multipart/form-data">
+<fieldset>
+<legend>Decode tile data into an image</legend>
+<label>Tile data: <input type="file" name="file" required></label>
+{{template "options" .}}
+<label>Tiles per row:
+<input type="number" name="cols" min="1" value="{{.Cols}}"></label>
+<label>Palette image (optional): <input type="file" name="palette" accept="image/*"></label>
+<button type="submit">Decode</button>
+</fieldset>
+</form>
+<form method="post" action="/encode" enctype="multipart/form-data">
+<fieldset>
+<legend>Encode a paletted image into tile data</legend>
+<label>Image: <input type="file" name="file" accept="image/*" required></label>
+{{template "options" .}}
+<button type="submit">Encode and download</button>
+</fieldset>
+</form>
+{{if .Image}}
+<h2>Decoded sheet</h2>
+<p><img class="sheet" src="{{.Image}}" alt="decoded tiles" width="{{.Width}}"></p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServeMethod(t *testing.T) {
+	for _, fn := range []http.HandlerFunc{serveDecode, serveEncode} {
+		w := httptest.NewRecorder()
+		fn(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("bad status: want 405, got %v", w.Code)
+		}
+		if a := w.Header().Get("Allow"); a != http.MethodPost {
+			t.Errorf("bad Allow header: %q", a)
+		}
+	}
+}
+
+func TestServeUploadSize(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("format", "p")
+	mw.WriteField("bpp", "2")
+	fw, err := mw.CreateFormFile("file", "big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(make([]byte, maxUploadSize+1))
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/decode", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	serveDecode(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad status: want 400, got %v", w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type SpectrumArgs struct {
+	Image  string `arg:"positional,required" help:"image file"`
+	Screen string `arg:"positional,required" help:"screen (.scr) file"`
+	Decode bool   `arg:"-d" help:"decode the screen file into the image"`
+}
+
+func (SpectrumArgs) Description() string {
+	return "Converts between a 256x192 image using the ZX Spectrum " +
+		"palette (0-7 normal,\n8-15 bright) and a Spectrum screen file. " +
+		"Each 8x8 cell can only use two\ncolors, which must all be normal " +
+		"or all bright; any clashes are listed.\nBy default, this encodes " +
+		"the image into the screen file."
+}
+
+func runSpectrumCommand(argv []string) error {
+	var args SpectrumArgs
+	mustParse("zxscr", &args, argv)
+
+	if args.Decode {
+		if err := checkImageFormat(args.Image); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(args.Screen)
+		if err != nil {
+			return err
+		}
+		img, err := tileconv.DecodeSpectrumScreen(data)
+		if err != nil {
+			return err
+		}
+		return saveImage(args.Image, img)
+	}
+
+	img, err := loadImage(args.Image)
+	if err != nil {
+		return err
+	}
+	data, err := tileconv.EncodeSpectrumScreen(img)
+	if err != nil {
+		return withClashDetails(err)
+	}
+	return os.WriteFile(args.Screen, data, 0o666)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type SpritesArgs struct {
+	Image  string `arg:"positional,required" help:"sprite sheet image file"`
+	Output string `arg:"positional,required" help:"output tile data file"`
+	Meta   string `arg:"positional,required" help:"output metadata file (JSON)"`
+
+	Format Format            `arg:"-f,required" help:"tile data format"`
+	Bpp    tileconv.BitDepth `arg:"-b,required" help:"bits per pixel; 1-8"`
+	Grid   string            `arg:"-g" help:"use a grid of WxH-pixel cells"`
+}
+
+func (SpritesArgs) Description() string {
+	return "Slices a sprite sheet into separate sprites, either by finding " +
+		"the objects\non the transparent background (color 0), or by " +
+		"splitting it into a grid of\ncells. Each sprite is trimmed to a " +
+		"whole number of tiles and encoded on its\nown, one after the " +
+		"other, and the metadata file tells where each one is."
+}
+
+func (SpritesArgs) Epilogue() string {
+	return `Metadata format (x and y are the sprite's position in the sheet, and
+offset and size give where its data is in the output file, in bytes):
+    {
+        "tileWidth": 8, "tileHeight": 8,
+        "sprites": [
+            {"x": 3, "y": 5, "width": 16, "height": 8, "cols": 2,
+             "rows": 1, "tiles": 2, "offset": 0, "size": 64},
+            ...
+        ]
+    }
+
+` + formatsHelp()
+}
+
+// SpriteSheetMeta is the contents of a sprite sheet metadata file.
+type SpriteSheetMeta struct {
+	TileWidth  int          `json:"tileWidth"`
+	TileHeight int          `json:"tileHeight"`
+	Sprites    []SpriteMeta `json:"sprites"`
+}
+
+// SpriteMeta describes a single sprite in a sprite sheet metadata file.
+type SpriteMeta struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Cols   int `json:"cols"`
+	Rows   int `json:"rows"`
+	Tiles  int `json:"tiles"`
+	Offset int `json:"offset"`
+	Size   int `json:"size"`
+}
+
+func runSpritesCommand(argv []string) error {
+	var args SpritesArgs
+	mustParse("sprites", &args, argv)
+
+	codec, err := args.Format.Codec(args.Bpp)
+	if err != nil {
+		return err
+	}
+	tw, th := tileconv.TileSize(codec)
+
+	img, err := loadImage(args.Image)
+	if err != nil {
+		return err
+	}
+
+	var sprites []tileconv.Sprite
+	if args.Grid != "" {
+		w, h, err := parseSize(args.Grid)
+		if err != nil {
+			return fmt.Errorf("invalid grid: %w", err)
+		}
+		sprites = tileconv.GridSprites(img, w, h, tw, th)
+	} else {
+		sprites = tileconv.FindSprites(img, tw, th)
+	}
+
+	out, err := os.Create(args.Output)
+	if err != nil {
+		return err
+	}
+	data, err := tileconv.EncodeSprites(img, sprites, out, codec)
+	tailError(&err, out.Close)
+	if err != nil {
+		return err
+	}
+
+	meta := SpriteSheetMeta{TileWidth: tw, TileHeight: th}
+	meta.Sprites = make([]SpriteMeta, 0, len(data))
+	for _, d := range data {
+		cols, rows := d.Tiles(tw, th)
+		meta.Sprites = append(meta.Sprites, SpriteMeta{
+			X:      d.Bounds.Min.X,
+			Y:      d.Bounds.Min.Y,
+			Width:  d.Bounds.Dx(),
+			Height: d.Bounds.Dy(),
+			Cols:   cols,
+			Rows:   rows,
+			Tiles:  cols * rows,
+			Offset: d.Offset,
+			Size:   d.Size,
+		})
+	}
+	js, err := json.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(args.Meta, append(js, '\n'), 0o666)
+}
+
+// parseSize parses a size given as WxH, where both must be positive.
+func parseSize(s string) (w, h int, err error) {
+	ws, hs, _ := strings.Cut(s, "x")
+	if w, err = strconv.Atoi(ws); err == nil {
+		h, err = strconv.Atoi(hs)
+	}
+	if err == nil && (w < 1 || h < 1) {
+		err = fmt.Errorf("size must be positive: %q", s)
+	}
+	return w, h, err
+}
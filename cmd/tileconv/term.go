@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// stty runs the stty command on the terminal connected to stdin, and
+// returns its output. This avoids needing platform-specific ioctls.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// makeRaw puts the terminal into raw mode, returning a function that
+// restores the previous terminal state.
+func makeRaw() (restore func() error, err error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := stty(state)
+		return err
+	}, nil
+}
+
+// termSize returns the size of the terminal, in character cells.
+func termSize() (cols, rows int, err error) {
+	out, err := stty("size")
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscan(out, &rows, &cols); err != nil {
+		return 0, 0, fmt.Errorf("bad terminal size %q: %w", out, err)
+	}
+	return cols, rows, nil
+}
+
+// readKey reads a single key press from the given raw-mode terminal,
+// returning it as a key name (e.g. "up" or "pgdn") or the typed text.
+func readKey(f *os.File) (string, error) {
+	buf := make([]byte, 16)
+	n, err := f.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	if name, ok := keyNames[string(buf[:n])]; ok {
+		return name, nil
+	}
+	return string(buf[:n]), nil
+}
+
+// keyNames maps the escape sequences of special keys to their names.
+var keyNames = map[string]string{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1b[5~": "pgup", "\x1b[6~": "pgdn",
+	"\x1b[H": "home", "\x1b[F": "end", "\x1b[1~": "home", "\x1b[4~": "end",
+	"\x1bOH": "home", "\x1bOF": "end",
+	"\x1b": "esc", "\x03": "ctrl-c", "\r": "enter",
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/color"
 	"image/gif"
 	"image/png"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,9 +18,15 @@ import (
 )
 
 func main() {
-	var args Args
-	arg.MustParse(&args)
-	if err := run(args); err != nil {
+	var err error
+	if cmd, ok := findCommand(os.Args[1:]); ok {
+		err = cmd.run(os.Args[2:])
+	} else {
+		var args Args
+		arg.MustParse(&args)
+		err = run(args)
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
@@ -31,53 +39,38 @@ type Args struct {
 	Format Format `arg:"-f,required" help:"tile data format; see below"`
 
 	Bpp tileconv.BitDepth `arg:"-b,required" help:"bits per pixel; 1-8"`
-}
 
-type Format string
+	Remap string `help:"change the color indexes; see below"`
+
+	Watch    bool          `arg:"-w" help:"keep running, redoing it when the input changes"`
+	Interval watchInterval `default:"500ms" help:"how often to check for changes with --watch"`
+}
 
 func (Args) Epilogue() string {
-	return `Tile data formats:
-    p, packed               : packed-pixel
-    tp, tileplanar          : planar, per tile
-    rp, rowplanar           : planar, per row
-    trpp, tilerowpairplanar : planar, pairs per row, rest per tile`
-}
-
-func (f *Format) UnmarshalText(text []byte) error {
-	switch string(text) {
-	case "p", "packed":
-	case "tp", "tileplanar":
-	case "rp", "rowplanar":
-	case "trpp", "tilerowpairplanar":
-	default:
-		return fmt.Errorf("unknown tile format %q", text)
-	}
-	*f = Format(text)
-	return nil
+	return formatsHelp() + "\n\n" + remapHelp + "\n\n" + commandsHelp()
 }
 
-func run(args Args) (e error) {
-	var codec tileconv.Codec
+func run(args Args) error {
+	if args.Watch {
+		files := func() []string { return []string{args.Input} }
+		return watchFiles(files, args.Interval, func() error {
+			if err := convertFile(args); err != nil {
+				return err
+			}
+			watchLog("Wrote %s", args.Output)
+			return nil
+		})
+	}
 
-	switch args.Format {
-	case "p", "packed":
-		codec = tileconv.Packed{
-			BitDepth: args.Bpp,
-		}
-	case "tp", "tileplanar":
-		codec = tileconv.TilePlanar{
-			BitDepth: args.Bpp,
-		}
-	case "rp", "rowplanar":
-		codec = tileconv.RowPlanar{
-			BitDepth: args.Bpp,
-		}
-	case "trpp", "tilerowpairplanar":
-		codec = tileconv.TileRowPairPlanar{
-			BitDepth: args.Bpp,
-		}
-	default:
-		return fmt.Errorf("unknown tile format: %q", args.Format)
+	return convertFile(args)
+}
+
+// convertFile does a single conversion as specified by the arguments,
+// either encoding or decoding the input file into the output file.
+func convertFile(args Args) error {
+	codec, err := args.Format.Codec(args.Bpp)
+	if err != nil {
+		return err
 	}
 
 	if args.Decode {
@@ -93,13 +86,31 @@ func runEncode(args Args, codec tileconv.Codec) (e error) {
 		return err
 	}
 
+	var remap tileconv.Remap
+	if args.Remap != "" {
+		remap, err = parseRemap(args.Remap, img)
+		if err != nil {
+			return err
+		}
+		// Check it here, to avoid leaving an empty output file behind.
+		if err := remap.CheckEncode(img, codec, args.Bpp); err != nil {
+			return err
+		}
+	}
+
 	out, err := os.Create(args.Output)
 	if err != nil {
 		return err
 	}
 	defer tailError(&e, out.Close)
 
-	if err := tileconv.Encode(img, out, codec); err != nil {
+	if args.Remap != "" {
+		return tileconv.EncodeRemapped(img, out, codec, remap, args.Bpp)
+	}
+
+	// This uses one goroutine per CPU, to speed up big sheets.
+	err = tileconv.EncodeParallel(context.Background(), img, out, codec, 0)
+	if err != nil {
 		return err
 	}
 
@@ -107,9 +118,8 @@ func runEncode(args Args, codec tileconv.Codec) (e error) {
 }
 
 func runDecode(args Args, codec tileconv.Codec) (e error) {
-	outFmt := strings.ToLower(filepath.Ext(args.Output))
-	if outFmt != ".png" && outFmt != ".gif" {
-		return fmt.Errorf("unknown image format: %q", outFmt)
+	if err := checkImageFormat(args.Output); err != nil {
+		return err
 	}
 
 	src, err := os.ReadFile(args.Input)
@@ -121,35 +131,111 @@ func runDecode(args Args, codec tileconv.Codec) (e error) {
 		return fmt.Errorf("input is not a whole number of tiles")
 	}
 
+	if args.Remap == "" {
+		return saveImage(args.Output, decodeSheet(src, codec, args.Bpp, 16))
+	}
+
+	remap, err := parseRemap(args.Remap, nil)
+	if err != nil {
+		return err
+	}
+	pal := makePalette(remapDepth(remap, args.Bpp))
+	img := newSheet(src, codec, pal, 16)
+	if err := tileconv.DecodeRemapped(src, img, codec, remap); err != nil {
+		return err
+	}
+
+	return saveImage(args.Output, img)
+}
+
+// decodeSheet decodes all the whole tiles in src into a new image that
+// is (at most) the given number of tiles wide.
+func decodeSheet(
+	src []byte, codec tileconv.Codec, bpp tileconv.BitDepth, maxCols int,
+) *image.Paletted {
+	img := newSheet(src, codec, makePalette(bpp), maxCols)
+
+	// This uses one goroutine per CPU, to speed up big sheets; it can
+	// not fail, since the context is never canceled.
+	_ = tileconv.DecodeParallel(context.Background(), src, img, codec, 0)
+
+	return img
+}
+
+// newSheet returns a new, blank image that has room for all the whole
+// tiles in src, being (at most) the given number of tiles wide.
+func newSheet(
+	src []byte, codec tileconv.Codec, pal color.Palette, maxCols int,
+) *image.Paletted {
 	tiles := len(src) / codec.Size()
-	rows := (tiles + 15) / 16
-	cols := 16
+	rows := (tiles + maxCols - 1) / maxCols
+	cols := maxCols
 	if rows < 2 {
 		cols = tiles
 	}
 
-	img := image.NewPaletted(
-		image.Rect(0, 0, cols*8, rows*8), makePalette(args.Bpp),
-	)
+	tw, th := tileconv.TileSize(codec)
+	return image.NewPaletted(image.Rect(0, 0, cols*tw, rows*th), pal)
+}
+
+// checkImageFormat returns an error if the file name does not have an
+// extension that saveImage knows how to write.
+func checkImageFormat(fn string) error {
+	switch outFmt := strings.ToLower(filepath.Ext(fn)); outFmt {
+	case ".png", ".gif", ".iff", ".ilbm", ".lbm",
+		".pi1", ".pi2", ".pi3":
+		return nil
+	default:
+		return fmt.Errorf("unknown image format: %q", outFmt)
+	}
+}
 
-	tileconv.Decode(src, img, codec)
+// saveImage writes the image to the given file, in the image format
+// indicated by the file name extension.
+func saveImage(fn string, img image.Image) (e error) {
+	outFmt := strings.ToLower(filepath.Ext(fn))
+	if err := checkImageFormat(fn); err != nil {
+		return err
+	}
 
-	out, err := os.Create(args.Output)
+	out, err := os.Create(fn)
 	if err != nil {
 		return err
 	}
 	defer tailError(&e, out.Close)
 
+	return writeImage(out, outFmt, img)
+}
+
+// writeImage writes the image to the given writer, in the image format
+// indicated by the given file name extension.
+func writeImage(out io.Writer, outFmt string, img image.Image) error {
 	switch outFmt {
 	case ".png":
 		return png.Encode(out, img)
 	case ".gif":
+		pi, ok := img.(*image.Paletted)
+		if !ok {
+			return gif.Encode(out, img, nil)
+		}
 		// When given an *image.Paletted (like we're doing), and the
 		// options.NumColors matches its palette, then the Quantizer and
 		// Drawer are not actually used, and the image is used as-is.
-		return gif.Encode(out, img, &gif.Options{
-			NumColors: len(img.Palette),
+		return gif.Encode(out, pi, &gif.Options{
+			NumColors: len(pi.Palette),
 		})
+	case ".iff", ".ilbm", ".lbm":
+		pi, ok := img.(*image.Paletted)
+		if !ok {
+			return fmt.Errorf("ILBM output requires a paletted image")
+		}
+		return writeILBM(out, pi)
+	case ".pi1", ".pi2", ".pi3":
+		pi, ok := img.(*image.Paletted)
+		if !ok {
+			return fmt.Errorf("Degas output requires a paletted image")
+		}
+		return writeDegas(out, degasResolution(outFmt), pi)
 	default:
 		return fmt.Errorf("unexpected image format: %q", outFmt)
 	}
@@ -174,7 +260,21 @@ func loadImage(fn string) (_ image.PalettedImage, e error) {
 	}
 	defer tailError(&e, f.Close)
 
-	img, _, err := image.Decode(f)
+	return decodePalettedImage(f, fn)
+}
+
+// decodePalettedImage decodes an image, which must be paletted. The
+// name is used to detect formats without a magic number, and in error
+// messages.
+func decodePalettedImage(
+	r io.Reader, name string,
+) (image.PalettedImage, error) {
+	// Degas files have no magic number, so we rely on the extension.
+	if degasResolution(name) >= 0 {
+		return decodeDegas(r)
+	}
+
+	img, _, err := image.Decode(r)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +283,7 @@ func loadImage(fn string) (_ image.PalettedImage, e error) {
 		return pi, nil
 	}
 
-	return nil, fmt.Errorf("not a paletted image: %s", fn)
+	return nil, fmt.Errorf("not a paletted image: %s", name)
 }
 
 func tailError(err *error, fn func() error) {
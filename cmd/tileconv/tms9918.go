@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type TMS9918Args struct {
+	Image    string `arg:"positional,required" help:"image file"`
+	Patterns string `arg:"positional,required" help:"pattern table file"`
+	Colors   string `arg:"positional,required" help:"color table file"`
+	Decode   bool   `arg:"-d" help:"decode the tables into the image"`
+	Cols     int    `default:"32" help:"max tiles per row when decoding"`
+}
+
+func (TMS9918Args) Description() string {
+	return "Converts between an image using the TMS9918 palette (as " +
+		"used by the MSX1,\nColecoVision and SG-1000) and Graphics II " +
+		"pattern and color tables. Each\n8-pixel row of a tile can only " +
+		"use two colors; any clashes are listed.\nBy default, this " +
+		"encodes the image into the two table files."
+}
+
+func runTMS9918Command(argv []string) error {
+	var args TMS9918Args
+	mustParse("tms9918", &args, argv)
+
+	if args.Decode {
+		if err := checkImageFormat(args.Image); err != nil {
+			return err
+		}
+		if args.Cols < 1 {
+			return fmt.Errorf("invalid number of columns: %v", args.Cols)
+		}
+		patterns, err := os.ReadFile(args.Patterns)
+		if err != nil {
+			return err
+		}
+		colors, err := os.ReadFile(args.Colors)
+		if err != nil {
+			return err
+		}
+		if len(patterns)%8 != 0 {
+			return fmt.Errorf("input is not a whole number of tiles")
+		}
+
+		tiles := len(patterns) / 8
+		rows := (tiles + args.Cols - 1) / args.Cols
+		cols := args.Cols
+		if rows < 2 {
+			cols = tiles
+		}
+		img := image.NewPaletted(
+			image.Rect(0, 0, cols*8, rows*8), tileconv.TMS9918Palette,
+		)
+		if err := tileconv.DecodeTMS9918(patterns, colors, img); err != nil {
+			return err
+		}
+		return saveImage(args.Image, img)
+	}
+
+	img, err := loadImage(args.Image)
+	if err != nil {
+		return err
+	}
+	patterns, colors, err := tileconv.EncodeTMS9918(img)
+	if err != nil {
+		return withClashDetails(err)
+	}
+	if err := os.WriteFile(args.Patterns, patterns, 0o666); err != nil {
+		return err
+	}
+	return os.WriteFile(args.Colors, colors, 0o666)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/edorfaus/tileconv"
+)
+
+type ViewArgs struct {
+	Input  string            `arg:"positional,required" help:"input file"`
+	Format Format            `arg:"-f" default:"p" help:"initial tile data format"`
+	Bpp    tileconv.BitDepth `arg:"-b" default:"1" help:"initial bits per pixel"`
+	Offset int               `arg:"-o" help:"initial byte offset"`
+	Cols   int               `arg:"-c" help:"tiles per row; default fits the terminal"`
+}
+
+func (ViewArgs) Description() string {
+	return "Interactively browses the tiles in a binary file, rendering " +
+		"them in the\nterminal with ANSI truecolor half-block characters."
+}
+
+func (ViewArgs) Epilogue() string {
+	return viewKeysHelp + "\n\n" + formatsHelp()
+}
+
+const viewKeysHelp = `Keys:
+    left, right  : move by one byte
+    , .          : move by one tile
+    up, down     : move by one row of tiles
+    pgup, pgdn   : move by one page
+    home, end    : go to the start or end of the file
+    f, F         : next or previous tile data format
+    b, B         : increase or decrease bits per pixel
+    1-8          : set bits per pixel
+    q, esc       : quit`
+
+func runViewCommand(argv []string) error {
+	var args ViewArgs
+	mustParse("view", &args, argv)
+	return runView(args)
+}
+
+func runView(args ViewArgs) (e error) {
+	data, err := os.ReadFile(args.Input)
+	if err != nil {
+		return err
+	}
+
+	v := &viewer{
+		data:   data,
+		bpp:    args.Bpp,
+		offset: args.Offset,
+		cols:   args.Cols,
+	}
+	v.formats, v.format = viewFormats(args.Format)
+	if v.offset < 0 || v.offset > len(data) {
+		return fmt.Errorf("offset %v is outside of the input", v.offset)
+	}
+	v.fixBpp()
+
+	restore, err := makeRaw()
+	if err != nil {
+		return err
+	}
+	defer tailError(&e, restore)
+
+	out := bufio.NewWriter(os.Stdout)
+	// Use the alternate screen, and hide the cursor while running.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(out, "\x1b[0m\x1b[?25h\x1b[?1049l")
+		tailError(&e, out.Flush)
+	}()
+
+	for {
+		cols, rows, err := termSize()
+		if err != nil {
+			return err
+		}
+		v.setScreenSize(cols, rows)
+
+		if err := v.render(out); err != nil {
+			return err
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+		key, err := readKey(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if !v.handleKey(key) {
+			return nil
+		}
+	}
+}
+
+// viewFormats returns the formats the viewer can switch between, and
+// the index of the given format in them.
+//
+// Custom formats are not in the global table, so they are added to the
+// end of a copy of it, to let the viewer switch back to them.
+func viewFormats(f Format) ([]formatInfo, int) {
+	list := append([]formatInfo(nil), formats...)
+	fi := findFormat(string(f))
+	for i := range formats {
+		if &formats[i] == fi {
+			return list, i
+		}
+	}
+	if fi != nil {
+		return append(list, *fi), len(list)
+	}
+	return list, 0
+}
+
+// viewer holds the state of the interactive tile viewer.
+type viewer struct {
+	data []byte
+
+	// formats is the list of formats to switch between, and format is
+	// the index of the current one.
+	formats []formatInfo
+	format  int
+
+	bpp    tileconv.BitDepth
+	offset int
+
+	// cols is the requested number of tiles per row, or 0 for auto.
+	cols int
+
+	// These are calculated from the terminal size.
+	tileCols, tileRows int
+}
+
+func (v *viewer) codec() tileconv.Codec {
+	return v.formats[v.format].Codec(v.bpp)
+}
+
+// setScreenSize updates the number of visible tiles based on the given
+// terminal size, leaving room for the status line at the bottom.
+func (v *viewer) setScreenSize(cols, rows int) {
+	tw, th := tileconv.TileSize(v.codec())
+	v.tileCols = cols / tw
+	if v.cols > 0 && v.cols < v.tileCols {
+		v.tileCols = v.cols
+	}
+	// Each character cell shows two pixel rows.
+	v.tileRows = (rows - 1) * 2 / th
+	if v.tileCols < 1 {
+		v.tileCols = 1
+	}
+	if v.tileRows < 1 {
+		v.tileRows = 1
+	}
+}
+
+// pageSize returns the number of bytes shown on a single screen.
+func (v *viewer) pageSize() int {
+	return v.tileCols * v.tileRows * v.codec().Size()
+}
+
+// move changes the offset by the given amount, clamped to the data.
+func (v *viewer) move(delta int) {
+	v.offset += delta
+	if v.offset > len(v.data)-1 {
+		v.offset = len(v.data) - 1
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+}
+
+// handleKey updates the viewer state for the given key, and returns
+// false if the viewer should exit.
+func (v *viewer) handleKey(key string) bool {
+	size := v.codec().Size()
+	switch key {
+	case "q", "Q", "esc", "ctrl-c":
+		return false
+	case "left":
+		v.move(-1)
+	case "right":
+		v.move(1)
+	case ",", "<":
+		v.move(-size)
+	case ".", ">":
+		v.move(size)
+	case "up":
+		v.move(-size * v.tileCols)
+	case "down":
+		v.move(size * v.tileCols)
+	case "pgup":
+		v.move(-v.pageSize())
+	case "pgdn", " ":
+		v.move(v.pageSize())
+	case "home":
+		v.offset = 0
+	case "end":
+		v.move(len(v.data))
+	case "f":
+		v.format = (v.format + 1) % len(v.formats)
+	case "F":
+		v.format = (v.format + len(v.formats) - 1) % len(v.formats)
+	case "b":
+		v.stepBpp(1)
+	case "B":
+		v.stepBpp(-1)
+	case "1", "2", "3", "4", "5", "6", "7", "8":
+		v.bpp = tileconv.BitDepth(key[0] - '0')
+	}
+	v.fixBpp()
+	return true
+}
+
+// fixBpp changes the bit depth to one that is supported by the current
+// format, if it isn't already.
+func (v *viewer) fixBpp() {
+	if fi := &v.formats[v.format]; !fi.Supports(v.bpp) {
+		v.bpp = fi.Depths[0]
+	}
+}
+
+// stepBpp moves the bit depth in the given direction to the nearest
+// one that is supported by the current format, if there is one.
+func (v *viewer) stepBpp(dir int) {
+	fi := &v.formats[v.format]
+	for bd := int(v.bpp) + dir; bd >= 1 && bd <= 8; bd += dir {
+		if fi.Supports(tileconv.BitDepth(bd)) {
+			v.bpp = tileconv.BitDepth(bd)
+			return
+		}
+	}
+}
+
+// render draws the current view, followed by the status line.
+func (v *viewer) render(w io.Writer) error {
+	codec := v.codec()
+	data := v.data[v.offset:]
+	if max := v.tileCols * v.tileRows * codec.Size(); len(data) > max {
+		data = data[:max]
+	}
+
+	tw, th := tileconv.TileSize(codec)
+	img := image.NewPaletted(
+		image.Rect(0, 0, v.tileCols*tw, v.tileRows*th), makePalette(v.bpp),
+	)
+	tileconv.Decode(data, img, codec)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	renderHalfBlocks(&b, img)
+	fmt.Fprintf(
+		&b, "\x1b[0m\x1b[K%s %vbpp  offset 0x%06X (%v) of 0x%06X",
+		v.formats[v.format].Names[0], v.bpp, v.offset, v.offset,
+		len(v.data),
+	)
+	b.WriteString("  [q: quit, f/b: format/bpp]\x1b[J")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderHalfBlocks writes the image as rows of upper half block
+// characters, using the foreground color for the upper pixel and the
+// background color for the lower one.
+func renderHalfBlocks(b *strings.Builder, img *image.Paletted) {
+	r := img.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y += 2 {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			tr, tg, tb, _ := img.At(x, y).RGBA()
+			br, bg, bb := tr, tg, tb
+			if y+1 < r.Max.Y {
+				br, bg, bb, _ = img.At(x, y+1).RGBA()
+			}
+			fmt.Fprintf(
+				b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+				tr>>8, tg>>8, tb>>8, br>>8, bg>>8, bb>>8,
+			)
+		}
+		b.WriteString("\x1b[0m\r\n")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// watchInterval is the interval for polling files with --watch, which
+// must be positive, to avoid busy-looping.
+type watchInterval time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (i *watchInterval) UnmarshalText(text []byte) error {
+	d, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("interval must be positive: %v", d)
+	}
+	*i = watchInterval(d)
+	return nil
+}
+
+// watchFiles calls fn once, and then again every time any of the files
+// returned by files is modified, as detected by polling their
+// modification times at the given interval. The list of files is
+// fetched again on every poll, so it can change over time.
+//
+// Errors returned by fn are printed, after which it keeps watching for
+// further changes. It only returns (with an error) if the interval is
+// not positive; otherwise it never returns.
+func watchFiles(
+	files func() []string, interval watchInterval, fn func() error,
+) error {
+	if interval <= 0 {
+		return errors.New("watch interval must be positive")
+	}
+	last := make(map[string]time.Time)
+	first := true
+	for {
+		changed := first
+		for _, f := range files() {
+			t, err := modTime(f)
+			if err != nil {
+				watchLog("Error: %v", err)
+			}
+			if !t.Equal(last[f]) {
+				last[f] = t
+				changed = true
+			}
+		}
+		first = false
+
+		if changed {
+			if err := fn(); err != nil {
+				watchLog("Error: %v", err)
+			}
+		}
+
+		time.Sleep(time.Duration(interval))
+	}
+}
+
+// modTime returns the modification time of the given file, or the zero
+// time if the file does not exist.
+func modTime(fn string) (time.Time, error) {
+	fi, err := os.Stat(fn)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// watchLog prints a timestamped message to stderr.
+func watchLog(format string, args ...interface{}) {
+	fmt.Fprintf(
+		os.Stderr, "%s %s\n",
+		time.Now().Format("15:04:05"), fmt.Sprintf(format, args...),
+	)
+}
@@ -1,5 +1,9 @@
 package tileconv
 
+import (
+	"image"
+)
+
 // Codec is the interface implemented by each tile image format type.
 //
 // Each system can thus pick the codec that corresponds to the way it
@@ -23,7 +27,7 @@ type Codec interface {
 	// that is ignored.
 	//
 	// Decode is not allowed to modify src, nor any part of dst except
-	// the color indexes inside the target area (the 8x8 tile at x,y).
+	// the color indexes inside the target area (the tile at x,y).
 	Decode(src []byte, dst DestImage, x, y int)
 
 	// Size returns the size of the encoded data for this codec.
@@ -33,6 +37,46 @@ type Codec interface {
 	Size() int
 }
 
+// TileSizer is an optional interface that can be implemented by a Codec
+// whose tiles are not 8x8 pixels, to specify the size of its tiles.
+type TileSizer interface {
+	// TileSize returns the width and height of a tile, in pixels.
+	TileSize() (w, h int)
+}
+
+// BulkCodec is an optional interface that can be implemented by a Codec
+// that can encode or decode a run of several tiles at once, working
+// directly on the pixels of an *image.Paletted. This lets it avoid the
+// per-pixel method calls, and only do its setup once per run.
+//
+// Encode and Decode use it (for tiles that are fully inside the image)
+// if the image is an *image.Paletted. The results must be the same as
+// when encoding or decoding the tiles one at a time.
+type BulkCodec interface {
+	Codec
+
+	// EncodeTiles encodes n tiles from src into dst, like calling
+	// Encode for each of them, where the first tile is at x,y and the
+	// others follow it to the right. The tiles are all fully inside the
+	// bounds of src, and dst is at least n*Size() bytes long.
+	EncodeTiles(src *image.Paletted, x, y, n int, dst []byte)
+
+	// DecodeTiles decodes n tiles from src into dst, like calling
+	// Decode for each of them, where the first tile is at x,y and the
+	// others follow it to the right. The tiles are all fully inside the
+	// bounds of dst, and src is at least n*Size() bytes long.
+	DecodeTiles(src []byte, dst *image.Paletted, x, y, n int)
+}
+
+// TileSize returns the size of the tiles used by the given codec. This
+// is 8x8 pixels unless the codec implements TileSizer.
+func TileSize(c Codec) (w, h int) {
+	if ts, ok := c.(TileSizer); ok {
+		return ts.TileSize()
+	}
+	return 8, 8
+}
+
 // Image represents an indexed-color image that a Codec can use as both
 // a source and a destination, both encoding from and decoding into it.
 type Image interface {
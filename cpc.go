@@ -0,0 +1,115 @@
+package tileconv
+
+// CPCSprite is a Codec that encodes each tile as an Amstrad CPC sprite,
+// in the screen memory byte format of one of the CPC's video modes.
+//
+// The pixels are stored in row-major order, with several pixels per
+// byte, but unlike Packed, the bits of each pixel are not contiguous.
+// Instead, each byte is split into groups of bits, where each group
+// holds one bit from each of the pixels in the byte (leftmost pixel in
+// the highest bit of the group):
+//
+//   - Mode 0 (4bpp): 2 pixels per byte, with the groups being (from
+//     the highest bits) bit 0, bit 2, bit 1 and bit 3 of the colors.
+//   - Mode 1 (2bpp): 4 pixels per byte, with the groups being bit 0
+//     and bit 1 of the colors.
+//   - Mode 2 (1bpp): 8 pixels per byte, like RowPlanar with BD1.
+//
+// The image has one pixel per CPC pixel, so mode 0 pixels (which are
+// shown double-wide) are not doubled.
+type CPCSprite struct {
+	// Mode is the CPC video mode (0, 1 or 2), which decides the bit
+	// depth. Other values are treated as mode 2.
+	Mode int
+
+	// Width and Height are the size of each sprite, in pixels (default
+	// 8). The width must be a multiple of the number of pixels per byte.
+	Width, Height int
+}
+
+var _ Codec = CPCSprite{}
+var _ TileSizer = CPCSprite{}
+
+// cpcBitGroups lists, for each mode, which bit of the color is stored
+// in each group of bits in a byte, starting from the highest group.
+var cpcBitGroups = [][]int{
+	{0, 2, 1, 3},
+	{0, 1},
+	{0},
+}
+
+func (c CPCSprite) bitGroups() []int {
+	if c.Mode < 0 || c.Mode >= len(cpcBitGroups) {
+		return cpcBitGroups[2]
+	}
+	return cpcBitGroups[c.Mode]
+}
+
+// BitDepth returns the bit depth of the sprite's video mode.
+func (c CPCSprite) BitDepth() BitDepth {
+	return BitDepth(len(c.bitGroups()))
+}
+
+// Size implements Codec, returning the size of a tile.
+func (c CPCSprite) Size() int {
+	w, h := c.TileSize()
+	return w * h * int(c.BitDepth()) / 8
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c CPCSprite) TileSize() (w, h int) {
+	w, h = c.Width, c.Height
+	if w <= 0 {
+		w = 8
+	}
+	if h <= 0 {
+		h = 8
+	}
+	return w, h
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c CPCSprite) Encode(src SourceImage, x, y int, dst []byte) {
+	groups := c.bitGroups()
+	ppb := 8 / len(groups)
+	w, h := c.TileSize()
+	i := 0
+	for iy := 0; iy < h; iy++ {
+		for ix := 0; ix < w; ix += ppb {
+			var b byte
+			for p := 0; p < ppb; p++ {
+				color := src.ColorIndexAt(x+ix+p, y+iy)
+				for g, bit := range groups {
+					if color&(1<<bit) != 0 {
+						b |= 0x80 >> (g*ppb + p)
+					}
+				}
+			}
+			dst[i] = b
+			i++
+		}
+	}
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c CPCSprite) Decode(src []byte, dst DestImage, x, y int) {
+	groups := c.bitGroups()
+	ppb := 8 / len(groups)
+	w, h := c.TileSize()
+	i := 0
+	for iy := 0; iy < h; iy++ {
+		for ix := 0; ix < w; ix += ppb {
+			b := src[i]
+			i++
+			for p := 0; p < ppb; p++ {
+				color := uint8(0)
+				for g, bit := range groups {
+					if b&(0x80>>(g*ppb+p)) != 0 {
+						color |= 1 << bit
+					}
+				}
+				dst.SetColorIndex(x+ix+p, y+iy, color)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestCPCSpriteSize(t *testing.T) {
+	check := func(c tileconv.CPCSprite, bd tileconv.BitDepth, size int) {
+		t.Helper()
+		verify(t, "bad bit depth", c.BitDepth(), bd)
+		verify(t, "bad size", c.Size(), size)
+	}
+	check(tileconv.CPCSprite{Mode: 0}, tileconv.BD4, 32)
+	check(tileconv.CPCSprite{Mode: 1}, tileconv.BD2, 16)
+	check(tileconv.CPCSprite{Mode: 2}, tileconv.BD1, 8)
+	check(tileconv.CPCSprite{Mode: 1, Width: 16, Height: 12}, tileconv.BD2, 48)
+
+	w, h := tileconv.TileSize(tileconv.CPCSprite{Width: 4, Height: 6})
+	verify(t, "bad tile size", [2]int{w, h}, [2]int{4, 6})
+}
+
+// cpcTestPix has distinct pixels in the first and last rows, and color
+// 0 elsewhere.
+var cpcTestPix = [][]uint8{
+	{1, 8, 2, 4, 0, 15, 10, 5},
+	{0, 0, 0, 0, 0, 0, 0, 0},
+	{0, 0, 0, 0, 0, 0, 0, 0},
+	{0, 0, 0, 0, 0, 0, 0, 0},
+	{0, 0, 0, 0, 0, 0, 0, 0},
+	{0, 0, 0, 0, 0, 0, 0, 0},
+	{0, 0, 0, 0, 0, 0, 0, 0},
+	{3, 0, 0, 0, 0, 0, 0, 9},
+}
+
+// cpcTestData returns the expected encoding of cpcTestPix in each mode.
+func cpcTestData() map[int][]byte {
+	// Mode 0: bits 0, 2, 1 and 3 of two pixels, interleaved.
+	mode0 := make([]byte, 32)
+	copy(mode0, []byte{0x81, 0x18, 0b01010101, 0b01011010})
+	copy(mode0[28:], []byte{0b10001000, 0, 0, 0b01000001})
+
+	// Mode 1: bits 0 and 1 of four pixels.
+	mode1 := make([]byte, 16)
+	copy(mode1, []byte{0b10000010, 0b01010110})
+	copy(mode1[14:], []byte{0b10001000, 0b00010000})
+
+	// Mode 2: one bit of eight pixels.
+	mode2 := make([]byte, 8)
+	mode2[0] = 0b10000101
+	mode2[7] = 0b10000001
+
+	return map[int][]byte{0: mode0, 1: mode1, 2: mode2}
+}
+
+func TestCPCSpriteEncode(t *testing.T) {
+	for mode, want := range cpcTestData() {
+		runCodecEncodeTests(
+			t, fmt.Sprintf("mode%d", mode), tileconv.CPCSprite{Mode: mode},
+			cpcTestPix, want,
+		)
+	}
+}
+
+func TestCPCSpriteDecode(t *testing.T) {
+	for mode, src := range cpcTestData() {
+		c := tileconv.CPCSprite{Mode: mode}
+		runCodecDecodeTests(
+			t, fmt.Sprintf("mode%d", mode), c, src,
+			pixBits(int(c.BitDepth()), cpcTestPix),
+		)
+	}
+}
+
+func TestCPCSpriteSheet(t *testing.T) {
+	pal := newTestPalette()
+	src := image.NewPaletted(image.Rect(0, 0, 32, 24), pal)
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i*7+i/5) & 0x03
+	}
+
+	var buf bytes.Buffer
+	c := tileconv.CPCSprite{Mode: 1, Width: 16, Height: 12}
+	if err := tileconv.Encode(src, &buf, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verify(t, "bad data length", buf.Len(), 4*48)
+
+	got := image.NewPaletted(src.Rect, pal)
+	tileconv.Decode(buf.Bytes(), got, c)
+	verify(t, "bad decoded pixels", got.Pix, src.Pix)
+}
@@ -18,13 +18,8 @@ import (
 // The destination image must have a palette that is large enough for
 // the bit depth of the codec, otherwise this may break the image.
 func Decode(src []byte, dst *image.Paletted, codec Codec) {
-	b := dst.Bounds()
-	sz := codec.Size()
-	from, to := 0, sz
-	for y := b.Min.Y; y < b.Max.Y && to <= len(src); y += 8 {
-		for x := b.Min.X; x < b.Max.X && to <= len(src); x += 8 {
-			codec.Decode(src[from:to], dst, x, y)
-			from, to = to, to+sz
-		}
+	t := newTileRows(dst.Bounds(), codec)
+	for row := 0; row < t.rowsIn(len(src)); row++ {
+		t.decodeRow(src, dst, codec, row)
 	}
 }
@@ -16,15 +16,12 @@ import (
 // This will make Encode ask the image for pixels outside of its bounds,
 // which typically returns a default color index (usually 0).
 func Encode(src image.PalettedImage, dst io.Writer, c Codec) error {
-	buf := make([]byte, c.Size())
-	b := src.Bounds()
-	for y := b.Min.Y; y < b.Max.Y; y += 8 {
-		for x := b.Min.X; x < b.Max.X; x += 8 {
-			c.Encode(src, x, y, buf)
-			_, err := dst.Write(buf)
-			if err != nil {
-				return err
-			}
+	t := newTileRows(src.Bounds(), c)
+	buf := make([]byte, t.rowSize())
+	for row := 0; row < t.rows; row++ {
+		t.encodeRow(src, c, row, buf)
+		if _, err := dst.Write(buf); err != nil {
+			return err
 		}
 	}
 	return nil
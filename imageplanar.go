@@ -0,0 +1,94 @@
+package tileconv
+
+import (
+	"image"
+)
+
+// ImagePlanar encodes or decodes a whole image as bit planes, the way
+// that e.g. the Amiga and Atari ST store their screens, rather than as
+// a sequence of tiles.
+//
+// Each plane row stores one bit of each pixel, with the leftmost pixel
+// in the high bit of the first byte. Plane 0 holds the low bit of the
+// color index.
+//
+// If Interleaved is false, each plane is stored in whole before the
+// next one; otherwise, all the planes of a row are stored before the
+// next row (as in the Amiga IFF ILBM format).
+type ImagePlanar struct {
+	BitDepth    BitDepth
+	Interleaved bool
+
+	// RowStride is the number of bytes in each row of each plane. If
+	// zero, each row is just large enough to hold the image width.
+	// If not zero, it must be at least that large.
+	RowStride int
+}
+
+// Stride returns the number of bytes in each row of each plane, for an
+// image of the given width.
+func (c ImagePlanar) Stride(width int) int {
+	if c.RowStride > 0 {
+		return c.RowStride
+	}
+	return (width + 7) / 8
+}
+
+// Size returns the size of the encoded data for an image of the given
+// size, in pixels.
+func (c ImagePlanar) Size(width, height int) int {
+	return c.Stride(width) * height * c.BitDepth.Planes()
+}
+
+// offset returns the offset of the given row of the given plane.
+func (c ImagePlanar) offset(stride, height, plane, row int) int {
+	if c.Interleaved {
+		return (row*c.BitDepth.Planes() + plane) * stride
+	}
+	return (plane*height + row) * stride
+}
+
+// Encode the given area of the image into the given buffer, which must
+// be at least Size() bytes long for the size of the area.
+//
+// Any padding bits at the end of each plane row are set to zero.
+func (c ImagePlanar) Encode(src SourceImage, r image.Rectangle, dst []byte) {
+	w, h := r.Dx(), r.Dy()
+	stride, planes := c.Stride(w), c.BitDepth.Planes()
+	for iy := 0; iy < h; iy++ {
+		for p := 0; p < planes; p++ {
+			row := dst[c.offset(stride, h, p, iy):][:stride]
+			for i := range row {
+				row[i] = 0
+			}
+		}
+		for ix := 0; ix < w; ix++ {
+			color := src.ColorIndexAt(r.Min.X+ix, r.Min.Y+iy)
+			bit := byte(0x80) >> (ix & 7)
+			for p := 0; p < planes; p++ {
+				if color&(1<<p) != 0 {
+					dst[c.offset(stride, h, p, iy)+ix/8] |= bit
+				}
+			}
+		}
+	}
+}
+
+// Decode the given data into the given area of the image. The data
+// must be at least Size() bytes long for the size of the area.
+func (c ImagePlanar) Decode(src []byte, dst DestImage, r image.Rectangle) {
+	w, h := r.Dx(), r.Dy()
+	stride, planes := c.Stride(w), c.BitDepth.Planes()
+	for iy := 0; iy < h; iy++ {
+		for ix := 0; ix < w; ix++ {
+			bit := byte(0x80) >> (ix & 7)
+			color := uint8(0)
+			for p := 0; p < planes; p++ {
+				if src[c.offset(stride, h, p, iy)+ix/8]&bit != 0 {
+					color |= 1 << p
+				}
+			}
+			dst.SetColorIndex(r.Min.X+ix, r.Min.Y+iy, color)
+		}
+	}
+}
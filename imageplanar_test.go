@@ -0,0 +1,89 @@
+package tileconv_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestImagePlanarSize(t *testing.T) {
+	check := func(c tileconv.ImagePlanar, w, h, wantStride, want int) {
+		t.Helper()
+		verify(t, "bad stride", c.Stride(w), wantStride)
+		verify(t, "bad size", c.Size(w, h), want)
+	}
+	check(tileconv.ImagePlanar{BitDepth: tileconv.BD1}, 8, 2, 1, 2)
+	check(tileconv.ImagePlanar{BitDepth: tileconv.BD2}, 9, 3, 2, 12)
+	check(tileconv.ImagePlanar{BitDepth: tileconv.BD5}, 320, 200, 40, 40000)
+	check(
+		tileconv.ImagePlanar{BitDepth: tileconv.BD4, RowStride: 4},
+		10, 2, 4, 32,
+	)
+}
+
+func TestImagePlanar(t *testing.T) {
+	// A 10x2 image, with 2 bits per pixel, plus junk in the high bits.
+	pix := [][]uint8{
+		{0xF1, 0x02, 0x03, 0x00, 0x01, 0x02, 0x03, 0x00, 0x03, 0x01},
+		{0x03, 0x03, 0x00, 0x00, 0x02, 0x02, 0x01, 0x01, 0x00, 0x02},
+	}
+	r := image.Rect(-2, 3, 8, 5)
+
+	// Plane rows, with two bytes per row (no custom stride).
+	r0p0 := []byte{0b10101010, 0b11000000}
+	r0p1 := []byte{0b01100110, 0b10000000}
+	r1p0 := []byte{0b11000011, 0b00000000}
+	r1p1 := []byte{0b11001100, 0b01000000}
+
+	join := func(rows ...[]byte) []byte {
+		var out []byte
+		for _, row := range rows {
+			out = append(out, row...)
+		}
+		return out
+	}
+	pad := func(row []byte) []byte {
+		return append(append([]byte{}, row...), 0, 0)
+	}
+
+	tests := []struct {
+		name  string
+		codec tileconv.ImagePlanar
+		data  []byte
+	}{
+		{
+			"planes", tileconv.ImagePlanar{BitDepth: tileconv.BD2},
+			join(r0p0, r1p0, r0p1, r1p1),
+		},
+		{
+			"interleaved",
+			tileconv.ImagePlanar{BitDepth: tileconv.BD2, Interleaved: true},
+			join(r0p0, r0p1, r1p0, r1p1),
+		},
+		{
+			"stride",
+			tileconv.ImagePlanar{
+				BitDepth: tileconv.BD2, Interleaved: true, RowStride: 4,
+			},
+			join(pad(r0p0), pad(r0p1), pad(r1p0), pad(r1p1)),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src := newTestImage(r.Min.X, r.Min.Y, pix)
+			got := make([]byte, tc.codec.Size(r.Dx(), r.Dy()))
+			for i := range got {
+				got[i] = 0xFF
+			}
+			tc.codec.Encode(src, r, got)
+			verify(t, "bad encoded data", got, tc.data)
+
+			img := newTestImage(0, 0, nil)
+			want := newTestImage(r.Min.X, r.Min.Y, pixBits(2, pix))
+			tc.codec.Decode(tc.data, img, r)
+			verify(t, "bad decoded image", img, want)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package tileconv
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+const (
+	// KoalaWidth and KoalaHeight are the size of a Koala image, in
+	// logical (double-wide) pixels.
+	KoalaWidth  = 160
+	KoalaHeight = 200
+
+	// KoalaSize is the size of a Koala file, including load address.
+	KoalaSize = 2 + 8000 + 1000 + 1000 + 1
+
+	// koalaLoadAddress is the load address written to Koala files.
+	koalaLoadAddress = 0x6000
+)
+
+// EncodeKoala encodes an image into a Koala Painter file, which holds a
+// Commodore 64 multicolor bitmap.
+//
+// The image is read starting at its top-left corner, and must use the
+// C64Palette color indexes (0-15). If widePixels is set, then each
+// logical pixel is two image pixels wide (making the image 320x200),
+// and only the left image pixel of each pair is used.
+//
+// Each 4x8 cell can use the given background color plus at most three
+// other colors. If any cells use more, a *ColorClashError is returned
+// that describes all of those cells.
+func EncodeKoala(
+	src image.PalettedImage, bg uint8, widePixels bool,
+) ([]byte, error) {
+	if bg > 15 {
+		return nil, fmt.Errorf("invalid background color: %v", bg)
+	}
+
+	scale := 1
+	if widePixels {
+		scale = 2
+	}
+
+	data := make([]byte, KoalaSize)
+	data[0], data[1] = koalaLoadAddress&0xFF, koalaLoadAddress>>8
+	bitmap := data[2:8002]
+	screen := data[8002:9002]
+	colorRAM := data[9002:10002]
+	data[10002] = bg
+
+	var clashes []ColorClash
+	min := src.Bounds().Min
+	for cy := 0; cy < KoalaHeight/8; cy++ {
+		for cx := 0; cx < KoalaWidth/4; cx++ {
+			cell := image.Rect(0, 0, 4*scale, 8).Add(
+				min.Add(image.Pt(cx*4*scale, cy*8)),
+			)
+
+			var slots [16]uint8
+			var colors []uint8
+			for _, c := range cellColors(src, cell, scale) {
+				if c > 15 {
+					return nil, fmt.Errorf(
+						"invalid C64 color %v in cell at %v", c, cell.Min,
+					)
+				}
+				if c != bg {
+					colors = append(colors, c)
+					slots[c] = uint8(len(colors))
+				}
+			}
+			if len(colors) > 3 {
+				clashes = append(clashes, ColorClash{
+					Cell: cell, Colors: colors, Max: 3,
+				})
+				continue
+			}
+			colors = append(colors, 0, 0, 0)
+
+			ci := cy*KoalaWidth/4 + cx
+			screen[ci] = colors[0]<<4 | colors[1]
+			colorRAM[ci] = colors[2]
+
+			for py := 0; py < 8; py++ {
+				var b byte
+				for px := 0; px < 4; px++ {
+					c := src.ColorIndexAt(
+						cell.Min.X+px*scale, cell.Min.Y+py,
+					)
+					b = b<<2 | slots[c]
+				}
+				bitmap[ci*8+py] = b
+			}
+		}
+	}
+
+	if len(clashes) > 0 {
+		return nil, &ColorClashError{Clashes: clashes}
+	}
+
+	return data, nil
+}
+
+// DecodeKoala decodes a Koala Painter file into a new image that uses
+// the C64Palette. The load address at the start of the file is
+// optional. If widePixels is set, then each logical pixel is decoded as
+// two image pixels, making the image 320x200 instead of 160x200.
+func DecodeKoala(data []byte, widePixels bool) (*image.Paletted, error) {
+	switch len(data) {
+	case KoalaSize:
+		data = data[2:]
+	case KoalaSize - 2:
+	default:
+		return nil, errors.New("invalid Koala file size")
+	}
+
+	scale := 1
+	if widePixels {
+		scale = 2
+	}
+
+	bitmap := data[0:8000]
+	screen := data[8000:9000]
+	colorRAM := data[9000:10000]
+	bg := data[10000] & 15
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, KoalaWidth*scale, KoalaHeight),
+		append(C64Palette[:0:0], C64Palette...),
+	)
+	for cy := 0; cy < KoalaHeight/8; cy++ {
+		for cx := 0; cx < KoalaWidth/4; cx++ {
+			ci := cy*KoalaWidth/4 + cx
+			colors := [4]uint8{
+				bg, screen[ci] >> 4, screen[ci] & 15, colorRAM[ci] & 15,
+			}
+			for py := 0; py < 8; py++ {
+				b := bitmap[ci*8+py]
+				for px := 0; px < 4; px++ {
+					c := colors[b>>6]
+					b <<= 2
+					for s := 0; s < scale; s++ {
+						x := (cx*4+px)*scale + s
+						img.SetColorIndex(x, cy*8+py, c)
+					}
+				}
+			}
+		}
+	}
+
+	return img, nil
+}
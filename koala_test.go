@@ -0,0 +1,116 @@
+package tileconv_test
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// newKoalaTestImage returns an image where each 4x8 cell uses the
+// background color 0 plus three other colors that depend on the cell.
+func newKoalaTestImage(scale int) *image.Paletted {
+	img := image.NewPaletted(
+		image.Rect(0, 0, 160*scale, 200), tileconv.C64Palette,
+	)
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 160; x++ {
+			cell := x/4 + y/8*40
+			c := uint8(0)
+			if n := (x + y) % 4; n > 0 {
+				c = uint8((cell+n*5)%15 + 1)
+			}
+			for s := 0; s < scale; s++ {
+				img.SetColorIndex(x*scale+s, y, c)
+			}
+		}
+	}
+	return img
+}
+
+func TestKoalaRoundTrip(t *testing.T) {
+	for _, wide := range []bool{false, true} {
+		scale := 1
+		if wide {
+			scale = 2
+		}
+		src := newKoalaTestImage(scale)
+
+		data, err := tileconv.EncodeKoala(src, 0, wide)
+		if err != nil {
+			t.Fatalf("wide=%v: unexpected encode error: %v", wide, err)
+		}
+		verify(t, "bad size", len(data), tileconv.KoalaSize)
+		verify(t, "bad load address", data[:2], []byte{0x00, 0x60})
+
+		got, err := tileconv.DecodeKoala(data, wide)
+		if err != nil {
+			t.Fatalf("wide=%v: unexpected decode error: %v", wide, err)
+		}
+		verify(t, "bad decoded image", got, src)
+
+		// The load address is optional when decoding.
+		got, err = tileconv.DecodeKoala(data[2:], wide)
+		if err != nil {
+			t.Fatalf("wide=%v: unexpected decode error: %v", wide, err)
+		}
+		verify(t, "bad decoded image without address", got, src)
+	}
+}
+
+func TestKoalaLayout(t *testing.T) {
+	src := image.NewPaletted(image.Rect(0, 0, 160, 200), tileconv.C64Palette)
+	// Second cell: background, except row 1 which also uses 5, 7 and 9.
+	for y := 0; y < 8; y++ {
+		for x := 4; x < 8; x++ {
+			src.SetColorIndex(x, y, 6)
+		}
+	}
+	src.SetColorIndex(5, 1, 5)
+	src.SetColorIndex(6, 1, 7)
+	src.SetColorIndex(7, 1, 9)
+
+	data, err := tileconv.EncodeKoala(src, 6, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first cell only has color 0, which is not the background.
+	verify(t, "bad first cell bitmap", data[2+0], byte(0b01010101))
+	verify(t, "bad first cell screen", data[8002], byte(0x00))
+
+	verify(t, "bad second cell bitmap", data[2+8+0], byte(0b00000000))
+	verify(t, "bad second cell bitmap", data[2+8+1], byte(0b00011011))
+	verify(t, "bad second cell screen", data[8003], byte(0x57))
+	verify(t, "bad second cell color", data[9003], byte(0x09))
+	verify(t, "bad background", data[10002], byte(6))
+}
+
+func TestKoalaClash(t *testing.T) {
+	src := image.NewPaletted(image.Rect(0, 0, 160, 200), tileconv.C64Palette)
+	for i := uint8(0); i < 4; i++ {
+		src.SetColorIndex(int(i), 0, i+1)
+		src.SetColorIndex(8+int(i), 8, i+1)
+	}
+
+	_, err := tileconv.EncodeKoala(src, 0, false)
+
+	var ce *tileconv.ColorClashError
+	if !errors.As(err, &ce) {
+		t.Fatalf("wrong error: want *ColorClashError, got %#v", err)
+	}
+	verify(t, "bad clashes", ce.Clashes, []tileconv.ColorClash{
+		{Cell: image.Rect(0, 0, 4, 8), Colors: []uint8{1, 2, 3, 4}, Max: 3},
+		{Cell: image.Rect(8, 8, 12, 16), Colors: []uint8{1, 2, 3, 4}, Max: 3},
+	})
+
+	_, err = tileconv.EncodeKoala(src, 16, false)
+	if err == nil {
+		t.Errorf("missing error for invalid background color")
+	}
+
+	if _, err := tileconv.DecodeKoala(make([]byte, 100), false); err == nil {
+		t.Errorf("missing error for invalid size")
+	}
+}
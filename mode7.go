@@ -0,0 +1,76 @@
+package tileconv
+
+import (
+	"bytes"
+	"errors"
+	"image"
+)
+
+const (
+	// Mode7MapSize is the width and height of the SNES Mode 7 tile map,
+	// in tiles. The map is stored with one byte per tile.
+	Mode7MapSize = 128
+
+	// Mode7Tiles is the max number of tiles in a Mode 7 tile set.
+	Mode7Tiles = 256
+
+	// Mode7VRAMSize is the size of the interleaved Mode 7 VRAM image.
+	Mode7VRAMSize = 2 * Mode7MapSize * Mode7MapSize
+)
+
+// mode7Codec is the codec used for the Mode 7 tile pixels.
+var mode7Codec = Packed{BitDepth: BD8}
+
+// EncodeMode7 encodes a tile set and tile map into an SNES Mode 7 VRAM
+// image, where the map is stored in the even bytes and the pixels of
+// the tiles are stored in the odd bytes.
+//
+// The tiles are read from the image as by Encode, using 8bpp packed
+// pixels, and there can be at most Mode7Tiles of them. The tile map can
+// be at most Mode7MapSize*Mode7MapSize bytes. Any unused space after
+// the tiles or map is filled with zeros.
+func EncodeMode7(tiles image.PalettedImage, tileMap []byte) ([]byte, error) {
+	var pix bytes.Buffer
+	if err := Encode(tiles, &pix, mode7Codec); err != nil {
+		return nil, err
+	}
+	if pix.Len() > Mode7VRAMSize/2 {
+		return nil, errors.New("too many tiles for Mode 7")
+	}
+	if len(tileMap) > Mode7VRAMSize/2 {
+		return nil, errors.New("tile map is too large for Mode 7")
+	}
+
+	vram := make([]byte, Mode7VRAMSize)
+	for i, v := range tileMap {
+		vram[i*2] = v
+	}
+	for i, v := range pix.Bytes() {
+		vram[i*2+1] = v
+	}
+	return vram, nil
+}
+
+// DecodeMode7 decodes an SNES Mode 7 VRAM image (as produced by
+// EncodeMode7) into its tile set and tile map.
+//
+// The tiles are decoded into dst as by Decode, so dst should be large
+// enough to hold Mode7Tiles tiles (e.g. 128x128 pixels) and have a
+// palette with 256 colors. The returned tile map has one byte per tile,
+// in row-major order.
+func DecodeMode7(vram []byte, dst *image.Paletted) ([]byte, error) {
+	if len(vram) != Mode7VRAMSize {
+		return nil, errors.New("invalid Mode 7 VRAM size")
+	}
+
+	tileMap := make([]byte, Mode7VRAMSize/2)
+	pix := make([]byte, Mode7VRAMSize/2)
+	for i := range tileMap {
+		tileMap[i] = vram[i*2]
+		pix[i] = vram[i*2+1]
+	}
+
+	Decode(pix, dst, mode7Codec)
+
+	return tileMap, nil
+}
@@ -0,0 +1,79 @@
+package tileconv_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestEncodeMode7(t *testing.T) {
+	tiles := image.NewPaletted(image.Rect(0, 0, 16, 8), newTestPalette())
+	for i := range tiles.Pix {
+		tiles.Pix[i] = uint8(i*7 + 3)
+	}
+	tileMap := []byte{1, 0, 1, 1}
+
+	vram, err := tileconv.EncodeMode7(tiles, tileMap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verify(t, "bad VRAM size", len(vram), tileconv.Mode7VRAMSize)
+
+	want := make([]byte, tileconv.Mode7VRAMSize)
+	for i, v := range tileMap {
+		want[i*2] = v
+	}
+	// The first tile is the left half of the image, row by row.
+	for i := 0; i < 64; i++ {
+		want[i*2+1] = tiles.ColorIndexAt(i%8, i/8)
+		want[(64+i)*2+1] = tiles.ColorIndexAt(8+i%8, i/8)
+	}
+	verify(t, "bad VRAM data", vram, want)
+}
+
+func TestEncodeMode7_Errors(t *testing.T) {
+	pal := newTestPalette()
+
+	big := image.NewPaletted(image.Rect(0, 0, 8, 8*257), pal)
+	if _, err := tileconv.EncodeMode7(big, nil); err == nil {
+		t.Errorf("missing error for too many tiles")
+	}
+
+	small := image.NewPaletted(image.Rect(0, 0, 8, 8), pal)
+	bigMap := make([]byte, 128*128+1)
+	if _, err := tileconv.EncodeMode7(small, bigMap); err == nil {
+		t.Errorf("missing error for too large tile map")
+	}
+}
+
+func TestDecodeMode7(t *testing.T) {
+	pal := newTestPalette()
+	tiles := image.NewPaletted(image.Rect(0, 0, 128, 128), pal)
+	for i := range tiles.Pix {
+		tiles.Pix[i] = uint8(i ^ i>>7)
+	}
+	tileMap := make([]byte, 128*128)
+	for i := range tileMap {
+		tileMap[i] = uint8(i * 13)
+	}
+
+	vram, err := tileconv.EncodeMode7(tiles, tileMap)
+	if err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	got := image.NewPaletted(tiles.Rect, pal)
+	gotMap, err := tileconv.DecodeMode7(vram, got)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+
+	verify(t, "bad tile map", gotMap, tileMap)
+	verifyImage(t, "bad tiles", 0, 0, got, tiles)
+
+	if _, err := tileconv.DecodeMode7(vram[1:], got); err == nil {
+		t.Errorf("missing error for bad VRAM size")
+	}
+}
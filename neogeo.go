@@ -0,0 +1,115 @@
+package tileconv
+
+import (
+	"errors"
+)
+
+// NeoGeoSprite is a Codec for Neo Geo sprite tiles, as stored in the
+// C-ROMs, but with the data of each ROM pair merged into one stream.
+//
+// Each tile is 16x16 pixels with 4 bits per pixel, stored as four 8x8
+// blocks in the order: top right, bottom right, top left, bottom left.
+// Each row of a block is stored as one byte per bit plane, with the
+// leftmost pixel in the lowest bit.
+//
+// On the actual hardware, the first ROM of each pair (C1, C3, ...)
+// holds planes 0 and 1, while the second ROM (C2, C4, ...) holds planes
+// 2 and 3. The merged data used by this codec has the bytes of those
+// two ROMs interleaved, starting with the first one, so each row is
+// stored as planes 0, 2, 1 and 3, in that order.
+//
+// Use SplitNeoGeoCROM and MergeNeoGeoCROM to convert between the
+// merged data and the separate ROMs.
+type NeoGeoSprite struct{}
+
+var _ Codec = NeoGeoSprite{}
+var _ TileSizer = NeoGeoSprite{}
+
+// neoGeoBlocks holds the position of each 8x8 block, in storage order.
+var neoGeoBlocks = [4][2]int{{8, 0}, {8, 8}, {0, 0}, {0, 8}}
+
+// neoGeoPlanes holds the plane stored in each byte of a merged row.
+var neoGeoPlanes = [4]int{0, 2, 1, 3}
+
+// Size implements Codec, returning the size of a tile.
+func (c NeoGeoSprite) Size() int {
+	return 128
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c NeoGeoSprite) TileSize() (w, h int) {
+	return 16, 16
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c NeoGeoSprite) Encode(src SourceImage, x, y int, dst []byte) {
+	di := 0
+	for _, b := range neoGeoBlocks {
+		for iy := 0; iy < 8; iy++ {
+			var planes [4]byte
+			for ix := 8 - 1; ix >= 0; ix-- {
+				color := src.ColorIndexAt(x+b[0]+ix, y+b[1]+iy)
+				for p := range planes {
+					planes[p] = (planes[p] << 1) | (color & 1)
+					color >>= 1
+				}
+			}
+			for _, p := range neoGeoPlanes {
+				dst[di] = planes[p]
+				di++
+			}
+		}
+	}
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c NeoGeoSprite) Decode(src []byte, dst DestImage, x, y int) {
+	si := 0
+	for _, b := range neoGeoBlocks {
+		for iy := 0; iy < 8; iy++ {
+			row := [8]uint8{}
+			for _, p := range neoGeoPlanes {
+				d := src[si]
+				si++
+				for ix := 0; ix < 8; ix++ {
+					row[ix] |= (d & 1) << p
+					d >>= 1
+				}
+			}
+			for ix := 0; ix < 8; ix++ {
+				dst.SetColorIndex(x+b[0]+ix, y+b[1]+iy, row[ix])
+			}
+		}
+	}
+}
+
+// SplitNeoGeoCROM splits merged data, as encoded by NeoGeoSprite, into
+// the contents of a pair of C-ROMs: the first (C1, C3, ...) and second
+// (C2, C4, ...) ROM of the pair.
+func SplitNeoGeoCROM(data []byte) (first, second []byte) {
+	first = make([]byte, (len(data)+1)/2)
+	second = make([]byte, len(data)/2)
+	for i, v := range data {
+		if i&1 == 0 {
+			first[i/2] = v
+		} else {
+			second[i/2] = v
+		}
+	}
+	return first, second
+}
+
+// MergeNeoGeoCROM merges the contents of a pair of C-ROMs into data
+// that can be decoded by NeoGeoSprite. It is the inverse of
+// SplitNeoGeoCROM, and the two ROMs must have the same size.
+func MergeNeoGeoCROM(first, second []byte) ([]byte, error) {
+	if len(first) != len(second) {
+		return nil, errors.New("Neo Geo C-ROM sizes do not match")
+	}
+	data := make([]byte, len(first)*2)
+	for i := range first {
+		data[i*2] = first[i]
+		data[i*2+1] = second[i]
+	}
+	return data, nil
+}
@@ -0,0 +1,93 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestNeoGeoSpriteSize(t *testing.T) {
+	c := tileconv.NeoGeoSprite{}
+	verify(t, "bad size", c.Size(), 128)
+	w, h := tileconv.TileSize(c)
+	verify(t, "bad tile size", [2]int{w, h}, [2]int{16, 16})
+}
+
+func TestNeoGeoSpriteEncode(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 16, 16), newTestPalette())
+	// Top-right block, row 0: colors 1, 2, 4, 8 in the first 4 pixels.
+	img.SetColorIndex(8, 0, 1)
+	img.SetColorIndex(9, 0, 2)
+	img.SetColorIndex(10, 0, 4)
+	img.SetColorIndex(11, 0, 8|0xF0)
+	// Bottom-right block, row 7: color 15 in the rightmost pixel.
+	img.SetColorIndex(15, 15, 15)
+	// Top-left block, row 1: color 3 in the leftmost pixel.
+	img.SetColorIndex(0, 1, 3)
+	// Bottom-left block, row 0: color 5 in the second pixel.
+	img.SetColorIndex(1, 8, 5)
+
+	c := tileconv.NeoGeoSprite{}
+	got := make([]byte, c.Size()+1)
+	got[c.Size()] = 0x5A
+	c.Encode(img, 0, 0, got)
+
+	want := make([]byte, c.Size()+1)
+	want[c.Size()] = 0x5A
+	// Each row is planes 0, 2, 1, 3, with the leftmost pixel in bit 0.
+	copy(want[0*32+0*4:], []byte{0b0001, 0b0100, 0b0010, 0b1000})
+	copy(want[1*32+7*4:], []byte{0x80, 0x80, 0x80, 0x80})
+	copy(want[2*32+1*4:], []byte{0b0001, 0b0000, 0b0001, 0b0000})
+	copy(want[3*32+0*4:], []byte{0b0010, 0b0010, 0b0000, 0b0000})
+	verify(t, "bad encoded data", got, want)
+
+	dec := image.NewPaletted(img.Rect, img.Palette)
+	c.Decode(got, dec, 0, 0)
+	for i := range img.Pix {
+		img.Pix[i] &= 0x0F
+	}
+	verify(t, "bad decoded pixels", dec.Pix, img.Pix)
+}
+
+func TestNeoGeoCROM(t *testing.T) {
+	pal := newTestPalette()
+	src := image.NewPaletted(image.Rect(0, 0, 32, 16), pal)
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i*13+i/5) & 0x0F
+	}
+
+	var buf bytes.Buffer
+	c := tileconv.NeoGeoSprite{}
+	if err := tileconv.Encode(src, &buf, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+
+	c1, c2 := tileconv.SplitNeoGeoCROM(data)
+	verify(t, "bad ROM sizes", [2]int{len(c1), len(c2)}, [2]int{128, 128})
+	// The first ROM has planes 0 and 1 of each row.
+	verify(
+		t, "bad first ROM", c1[:4],
+		[]byte{data[0], data[2], data[4], data[6]},
+	)
+	verify(
+		t, "bad second ROM", c2[:4],
+		[]byte{data[1], data[3], data[5], data[7]},
+	)
+
+	merged, err := tileconv.MergeNeoGeoCROM(c1, c2)
+	if err != nil {
+		t.Fatalf("unexpected merge error: %v", err)
+	}
+	verify(t, "bad merged data", merged, data)
+
+	got := image.NewPaletted(src.Rect, pal)
+	tileconv.Decode(merged, got, c)
+	verify(t, "bad decoded pixels", got.Pix, src.Pix)
+
+	if _, err := tileconv.MergeNeoGeoCROM(c1, c2[1:]); err == nil {
+		t.Errorf("missing error for mismatched ROM sizes")
+	}
+}
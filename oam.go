@@ -0,0 +1,634 @@
+package tileconv
+
+import (
+	"errors"
+	"image"
+	"sort"
+)
+
+// SpriteShape is the size of a hardware sprite, in tiles.
+type SpriteShape struct {
+	Cols, Rows int
+}
+
+// SpriteHardware describes the hardware sprites that a console has, for
+// use with BuildOAM.
+type SpriteHardware struct {
+	// Shapes lists the sprite sizes that can be used.
+	Shapes []SpriteShape
+
+	// ColumnMajor makes the tiles of each sprite be stored column by
+	// column, instead of row by row.
+	ColumnMajor bool
+
+	// TileStride is the number of tiles from the start of one row of a
+	// sprite's tiles to the start of the next, for hardware that takes
+	// the tiles of a sprite from a grid of tiles, like the SNES. If it
+	// is 0, the tiles of each sprite are stored one after the other.
+	TileStride int
+
+	// Flips makes sprites that are flipped copies of each other share
+	// the same tiles, by using the flip flags of the hardware.
+	Flips bool
+}
+
+// These are the sprite hardware of some common consoles.
+var (
+	// NES8x8Sprites is the NES (and Master System) with 8x8 sprites.
+	NES8x8Sprites = SpriteHardware{
+		Shapes: []SpriteShape{{1, 1}},
+		Flips:  true,
+	}
+
+	// NES8x16Sprites is the NES with 8x16 sprites.
+	NES8x16Sprites = SpriteHardware{
+		Shapes: []SpriteShape{{1, 2}},
+		Flips:  true,
+	}
+
+	// SNESSprites is the SNES, indexed by the object size setting (the
+	// top 3 bits of OBSEL), which selects the small and large sizes.
+	// The tiles are laid out on a 16-tile-wide grid, as in VRAM.
+	SNESSprites = [8]SpriteHardware{
+		snesSprites(SpriteShape{1, 1}, SpriteShape{2, 2}),
+		snesSprites(SpriteShape{1, 1}, SpriteShape{4, 4}),
+		snesSprites(SpriteShape{1, 1}, SpriteShape{8, 8}),
+		snesSprites(SpriteShape{2, 2}, SpriteShape{4, 4}),
+		snesSprites(SpriteShape{2, 2}, SpriteShape{8, 8}),
+		snesSprites(SpriteShape{4, 4}, SpriteShape{8, 8}),
+		snesSprites(SpriteShape{2, 4}, SpriteShape{4, 8}),
+		snesSprites(SpriteShape{2, 4}, SpriteShape{4, 4}),
+	}
+
+	// GBASprites is the Game Boy Advance, with 1D tile mapping.
+	GBASprites = SpriteHardware{
+		Shapes: []SpriteShape{
+			{1, 1}, {2, 2}, {4, 4}, {8, 8},
+			{2, 1}, {4, 1}, {4, 2}, {8, 4},
+			{1, 2}, {1, 4}, {2, 4}, {4, 8},
+		},
+		Flips: true,
+	}
+
+	// MegaDriveSprites is the Mega Drive (Genesis).
+	MegaDriveSprites = SpriteHardware{
+		Shapes: []SpriteShape{
+			{1, 1}, {2, 1}, {3, 1}, {4, 1},
+			{1, 2}, {2, 2}, {3, 2}, {4, 2},
+			{1, 3}, {2, 3}, {3, 3}, {4, 3},
+			{1, 4}, {2, 4}, {3, 4}, {4, 4},
+		},
+		ColumnMajor: true,
+		Flips:       true,
+	}
+)
+
+func snesSprites(small, large SpriteShape) SpriteHardware {
+	return SpriteHardware{
+		Shapes:     []SpriteShape{small, large},
+		TileStride: 16,
+		Flips:      true,
+	}
+}
+
+// OAMEntry is a single hardware sprite, as placed by BuildOAM.
+type OAMEntry struct {
+	// X and Y are the position of the top-left corner of the sprite,
+	// relative to the top-left corner of the frame. They can be negative.
+	X, Y int
+
+	Shape SpriteShape
+
+	// Tile is the index of the first tile of the sprite.
+	Tile int
+
+	HFlip, VFlip bool
+}
+
+// BuildOAM covers all the pixels of the frame that are not color 0 with
+// hardware sprites, trying to use as few of them as it can, and encodes
+// their tiles with the given codec. It returns the sprites and the tile
+// data.
+//
+// The sprites are placed from the top-left, with a few different ways
+// of choosing the shape and position of each: covering the most pixels,
+// covering the most pixels per tile, and avoiding empty tiles. After
+// dropping any sprites that the others make unnecessary, the one that
+// needs the fewest sprites (and then the fewest tiles) is kept. Then, a
+// limited search through the most promising other choices looks for a
+// way to do it with even fewer sprites. This usually finds the fewest
+// possible sprites, but since the search is limited, it can not promise
+// to.
+func BuildOAM(
+	src image.PalettedImage, hw SpriteHardware, c Codec,
+) ([]OAMEntry, []byte, error) {
+	if len(hw.Shapes) == 0 {
+		return nil, nil, errors.New("no sprite shapes given")
+	}
+	for _, s := range hw.Shapes {
+		if s.Cols < 1 || s.Rows < 1 {
+			return nil, nil, errors.New("invalid sprite shape")
+		}
+		if hw.TileStride < 0 || hw.TileStride > 0 && s.Cols > hw.TileStride {
+			return nil, nil, errors.New("sprite shape wider than tile stride")
+		}
+	}
+	if c.Size() <= 0 {
+		return nil, nil, errors.New("codec has no tile data")
+	}
+
+	o := oamBuilder{src: src, hw: hw, c: c, known: map[oamKey]int{}}
+	o.tw, o.th = TileSize(c)
+	o.b = src.Bounds()
+	o.opaque = make([]bool, o.b.Dx()*o.b.Dy())
+	for y := o.b.Min.Y; y < o.b.Max.Y; y++ {
+		for x := o.b.Min.X; x < o.b.Max.X; x++ {
+			o.opaque[o.index(x, y)] = src.ColorIndexAt(x, y) != 0
+		}
+	}
+
+	var best []oamSprite
+	for _, better := range oamStrategies {
+		sprites := o.prune(o.cover(better))
+		if best == nil || o.fewer(sprites, best) {
+			best = sprites
+		}
+	}
+	best = o.search(best)
+
+	entries := make([]OAMEntry, 0, len(best))
+	for _, s := range best {
+		e := OAMEntry{
+			X:     s.r.Min.X - o.b.Min.X,
+			Y:     s.r.Min.Y - o.b.Min.Y,
+			Shape: s.shape,
+		}
+		e.Tile, e.HFlip, e.VFlip = o.findTiles(s.r, s.shape)
+		entries = append(entries, e)
+	}
+
+	return entries, o.tileData(), nil
+}
+
+// oamSprite is a sprite that has been placed, but has no tiles yet.
+type oamSprite struct {
+	r     image.Rectangle
+	shape SpriteShape
+}
+
+// oamCandidate describes a possible placement of a sprite.
+type oamCandidate struct {
+	// covered is the number of pixels it covers that were left to cover.
+	covered int
+
+	// tiles is the number of tiles, and empty is the number of those that
+	// cover none of the pixels that were left to cover.
+	tiles, empty int
+}
+
+// oamStrategies are the ways that BuildOAM tries to choose sprites, each
+// returning true if the first candidate is better than the second.
+var oamStrategies = []func(a, b oamCandidate) bool{
+	// The most pixels, and then the fewest tiles.
+	func(a, b oamCandidate) bool {
+		if a.covered != b.covered {
+			return a.covered > b.covered
+		}
+		return a.tiles < b.tiles
+	},
+
+	// The most pixels per tile, and then the most pixels.
+	func(a, b oamCandidate) bool {
+		if d := a.covered*b.tiles - b.covered*a.tiles; d != 0 {
+			return d > 0
+		}
+		return a.covered > b.covered
+	},
+
+	// No empty tiles, and then the most pixels and the fewest tiles.
+	func(a, b oamCandidate) bool {
+		if (a.empty == 0) != (b.empty == 0) {
+			return a.empty == 0
+		}
+		if a.covered != b.covered {
+			return a.covered > b.covered
+		}
+		return a.tiles < b.tiles
+	},
+}
+
+// oamKey identifies the contents of a sprite, for sharing tiles.
+type oamKey struct {
+	shape SpriteShape
+	pix   string
+}
+
+type oamBuilder struct {
+	src    image.PalettedImage
+	hw     SpriteHardware
+	c      Codec
+	tw, th int
+	b      image.Rectangle
+
+	// opaque records which pixels need to be covered.
+	opaque []bool
+
+	// known maps the contents of the sprites that have had their tiles
+	// stored to the index of their first tile.
+	known map[oamKey]int
+
+	// slots holds the encoded tiles, with nil for unused tiles.
+	slots [][]byte
+}
+
+func (o *oamBuilder) index(x, y int) int {
+	return (y-o.b.Min.Y)*o.b.Dx() + x - o.b.Min.X
+}
+
+// size returns the size of the shape, in pixels.
+func (o *oamBuilder) size(s SpriteShape) (w, h int) {
+	return s.Cols * o.tw, s.Rows * o.th
+}
+
+// count returns how well a sprite of the given shape at the given area
+// covers the pixels that are left to cover.
+func (o *oamBuilder) count(
+	r image.Rectangle, s SpriteShape, left []bool,
+) oamCandidate {
+	c := oamCandidate{tiles: s.Cols * s.Rows}
+	for ty := r.Min.Y; ty < r.Max.Y; ty += o.th {
+		for tx := r.Min.X; tx < r.Max.X; tx += o.tw {
+			t := image.Rect(tx, ty, tx+o.tw, ty+o.th).Intersect(o.b)
+			n := 0
+			for y := t.Min.Y; y < t.Max.Y; y++ {
+				for x := t.Min.X; x < t.Max.X; x++ {
+					if left[o.index(x, y)] {
+						n++
+					}
+				}
+			}
+			if n == 0 {
+				c.empty++
+			}
+			c.covered += n
+		}
+	}
+	return c
+}
+
+// cover places sprites until all the pixels are covered, choosing each
+// one with the given strategy.
+func (o *oamBuilder) cover(better func(a, b oamCandidate) bool) []oamSprite {
+	left := append([]bool(nil), o.opaque...)
+	var sprites []oamSprite
+	for i := range left {
+		if left[i] {
+			p := image.Pt(i%o.b.Dx(), i/o.b.Dx()).Add(o.b.Min)
+			sprites = append(sprites, o.place(p, better, left))
+		}
+	}
+	return sprites
+}
+
+// place returns a sprite that covers the given pixel, which must be the
+// first one left to cover, so that there are none above it, or to its
+// left on the same row, and marks the pixels it covers as covered.
+func (o *oamBuilder) place(
+	p image.Point, better func(a, b oamCandidate) bool, left []bool,
+) oamSprite {
+	var best oamSprite
+	var bestScore oamCandidate
+	for _, s := range o.hw.Shapes {
+		w, h := o.size(s)
+		for x := p.X; x > p.X-w; x-- {
+			r := image.Rect(x, p.Y, x+w, p.Y+h)
+			score := o.count(r, s, left)
+			if best.r.Empty() || better(score, bestScore) {
+				best, bestScore = oamSprite{r, s}, score
+			}
+		}
+	}
+
+	covered := best.r.Intersect(o.b)
+	for y := covered.Min.Y; y < covered.Max.Y; y++ {
+		for x := covered.Min.X; x < covered.Max.X; x++ {
+			left[o.index(x, y)] = false
+		}
+	}
+	return best
+}
+
+// prune returns the sprites without those whose pixels are all covered
+// by the other sprites, trying to drop the biggest ones first.
+func (o *oamBuilder) prune(sprites []oamSprite) []oamSprite {
+	layers := make([]int, len(o.opaque))
+	each := func(s oamSprite, fn func(i int)) {
+		r := s.r.Intersect(o.b)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if i := o.index(x, y); o.opaque[i] {
+					fn(i)
+				}
+			}
+		}
+	}
+	for _, s := range sprites {
+		each(s, func(i int) { layers[i]++ })
+	}
+
+	order := make([]int, len(sprites))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := sprites[order[i]].shape, sprites[order[j]].shape
+		return a.Cols*a.Rows > b.Cols*b.Rows
+	})
+
+	dropped := make([]bool, len(sprites))
+	for _, i := range order {
+		needed := false
+		each(sprites[i], func(p int) { needed = needed || layers[p] < 2 })
+		if !needed {
+			dropped[i] = true
+			each(sprites[i], func(p int) { layers[p]-- })
+		}
+	}
+
+	kept := sprites[:0:0]
+	for i, s := range sprites {
+		if !dropped[i] {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// fewer returns true if the first list needs fewer sprites than the
+// second, or as many sprites but fewer tiles.
+func (o *oamBuilder) fewer(a, b []oamSprite) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	tiles := func(list []oamSprite) int {
+		n := 0
+		for _, s := range list {
+			n += s.shape.Cols * s.shape.Rows
+		}
+		return n
+	}
+	return tiles(a) < tiles(b)
+}
+
+// oamSearchSteps limits how many sprites the search may try to place,
+// and oamSearchWidth how many choices it tries for each sprite.
+const (
+	oamSearchSteps = 20000
+	oamSearchWidth = 6
+)
+
+// search does a depth-first search for a way to cover the frame with
+// fewer sprites than the given ones, returning the best one it found.
+func (o *oamBuilder) search(best []oamSprite) []oamSprite {
+	maxArea, left, remaining := 0, append([]bool(nil), o.opaque...), 0
+	for _, s := range o.hw.Shapes {
+		if w, h := o.size(s); w*h > maxArea {
+			maxArea = w * h
+		}
+	}
+	for _, l := range left {
+		if l {
+			remaining++
+		}
+	}
+
+	steps := 0
+	var current []oamSprite
+	var try func(start, remaining int)
+	try = func(start, remaining int) {
+		if remaining == 0 {
+			if len(current) < len(best) {
+				best = append([]oamSprite(nil), current...)
+			}
+			return
+		}
+		// Even using only the biggest shape, fully covered, this would
+		// not need fewer sprites than the best so far.
+		need := (remaining + maxArea - 1) / maxArea
+		if len(current)+need >= len(best) || steps >= oamSearchSteps {
+			return
+		}
+		for !left[start] {
+			start++
+		}
+		p := image.Pt(start%o.b.Dx(), start/o.b.Dx()).Add(o.b.Min)
+
+		for _, c := range o.choices(p, left) {
+			steps++
+			var undo []int
+			r := c.r.Intersect(o.b)
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					if i := o.index(x, y); left[i] {
+						left[i] = false
+						undo = append(undo, i)
+					}
+				}
+			}
+			current = append(current, c)
+			try(start, remaining-len(undo))
+			current = current[:len(current)-1]
+			for _, i := range undo {
+				left[i] = true
+			}
+		}
+	}
+	try(0, remaining)
+
+	return best
+}
+
+// choices returns the best ways to place a sprite that covers the given
+// pixel, for the search, with the ones that cover the most pixels first.
+func (o *oamBuilder) choices(p image.Point, left []bool) []oamSprite {
+	type choice struct {
+		s     oamSprite
+		score oamCandidate
+	}
+	var list []choice
+	for _, s := range o.hw.Shapes {
+		w, h := o.size(s)
+		for x := p.X; x > p.X-w; x-- {
+			r := image.Rect(x, p.Y, x+w, p.Y+h)
+			list = append(list, choice{oamSprite{r, s}, o.count(r, s, left)})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return oamStrategies[0](list[i].score, list[j].score)
+	})
+
+	var out []oamSprite
+	for i := 0; i < len(list) && len(out) < oamSearchWidth; i++ {
+		// Skip choices that cover the same pixels as a better one.
+		dup := false
+		for _, c := range out {
+			if o.sameCover(c.r, list[i].s.r, left) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, list[i].s)
+		}
+	}
+	return out
+}
+
+// sameCover returns true if the two areas cover the same pixels of the
+// ones that are left to cover.
+func (o *oamBuilder) sameCover(a, b image.Rectangle, left []bool) bool {
+	u := a.Union(b).Intersect(o.b)
+	for y := u.Min.Y; y < u.Max.Y; y++ {
+		for x := u.Min.X; x < u.Max.X; x++ {
+			p := image.Pt(x, y)
+			if left[o.index(x, y)] && p.In(a) != p.In(b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pixels returns the color indexes of the given area of the frame, in
+// row-major order, optionally flipped.
+func (o *oamBuilder) pixels(r image.Rectangle, hflip, vflip bool) string {
+	pix := make([]byte, 0, r.Dx()*r.Dy())
+	for iy := 0; iy < r.Dy(); iy++ {
+		y := r.Min.Y + iy
+		if vflip {
+			y = r.Max.Y - 1 - iy
+		}
+		for ix := 0; ix < r.Dx(); ix++ {
+			x := r.Min.X + ix
+			if hflip {
+				x = r.Max.X - 1 - ix
+			}
+			var c uint8
+			if (image.Point{x, y}).In(o.b) {
+				c = o.src.ColorIndexAt(x, y)
+			}
+			pix = append(pix, c)
+		}
+	}
+	return string(pix)
+}
+
+// findTiles returns the first tile of a sprite for the given area of the
+// frame, reusing the tiles of an earlier sprite if it has the same (or
+// if allowed, flipped) contents, and storing new tiles otherwise.
+func (o *oamBuilder) findTiles(
+	r image.Rectangle, s SpriteShape,
+) (tile int, hflip, vflip bool) {
+	flips := 1
+	if o.hw.Flips {
+		flips = 4
+	}
+	for f := 0; f < flips; f++ {
+		hflip, vflip = f&1 != 0, f&2 != 0
+		key := oamKey{s, o.pixels(r, hflip, vflip)}
+		if tile, ok := o.known[key]; ok {
+			return tile, hflip, vflip
+		}
+	}
+
+	tile = o.alloc(s)
+	o.known[oamKey{s, o.pixels(r, false, false)}] = tile
+
+	img := oamSpriteImage{o}
+	for row := 0; row < s.Rows; row++ {
+		for col := 0; col < s.Cols; col++ {
+			buf := make([]byte, o.c.Size())
+			o.c.Encode(img, r.Min.X+col*o.tw, r.Min.Y+row*o.th, buf)
+			o.slots[o.slot(tile, s, col, row)] = buf
+		}
+	}
+	return tile, false, false
+}
+
+// slot returns the index of the given tile of a sprite whose first tile
+// is at the given index.
+func (o *oamBuilder) slot(tile int, s SpriteShape, col, row int) int {
+	switch {
+	case o.hw.TileStride > 0:
+		return tile + row*o.hw.TileStride + col
+	case o.hw.ColumnMajor:
+		return tile + col*s.Rows + row
+	default:
+		return tile + row*s.Cols + col
+	}
+}
+
+// alloc returns the index of the first tile of a new sprite of the
+// given shape, reserving the tiles that it needs.
+func (o *oamBuilder) alloc(s SpriteShape) int {
+	stride := o.hw.TileStride
+	if stride <= 0 {
+		tile := len(o.slots)
+		o.slots = append(o.slots, make([][]byte, s.Cols*s.Rows)...)
+		return tile
+	}
+
+	// Find the first spot in the grid where the sprite's tiles are free.
+	for tile := 0; ; tile++ {
+		if tile%stride+s.Cols > stride {
+			continue
+		}
+		free := true
+		for row := 0; row < s.Rows && free; row++ {
+			for col := 0; col < s.Cols && free; col++ {
+				i := o.slot(tile, s, col, row)
+				free = i >= len(o.slots) || o.slots[i] == nil
+			}
+		}
+		if free {
+			if end := o.slot(tile, s, s.Cols, s.Rows-1); end > len(o.slots) {
+				o.slots = append(o.slots, make([][]byte, end-len(o.slots))...)
+			}
+			return tile
+		}
+	}
+}
+
+// tileData returns the stored tiles, with blank tiles in unused slots.
+func (o *oamBuilder) tileData() []byte {
+	blank := make([]byte, o.c.Size())
+	o.c.Encode(blankImage{}, 0, 0, blank)
+	data := make([]byte, 0, len(o.slots)*o.c.Size())
+	for _, t := range o.slots {
+		if t == nil {
+			t = blank
+		}
+		data = append(data, t...)
+	}
+	return data
+}
+
+// oamSpriteImage is the image that the tiles of a sprite are encoded
+// from, which is color 0 outside of the frame.
+type oamSpriteImage struct {
+	o *oamBuilder
+}
+
+func (i oamSpriteImage) ColorIndexAt(x, y int) uint8 {
+	if !(image.Point{x, y}).In(i.o.b) {
+		return 0
+	}
+	return i.o.src.ColorIndexAt(x, y)
+}
+
+// blankImage is an image where every pixel is color 0.
+type blankImage struct{}
+
+func (blankImage) ColorIndexAt(x, y int) uint8 {
+	return 0
+}
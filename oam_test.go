@@ -0,0 +1,229 @@
+package tileconv_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestBuildOAM_Flips(t *testing.T) {
+	// Two 8x8 tiles that are mirror images of each other, side by side.
+	src := image.NewPaletted(image.Rect(0, 0, 16, 8), make(color.Palette, 4))
+	for y := 0; y < 8; y++ {
+		for x := 0; x <= y; x++ {
+			src.SetColorIndex(x, y, uint8(1+x%3))
+			src.SetColorIndex(15-x, y, uint8(1+x%3))
+		}
+	}
+	c := tileconv.RowPlanar{BitDepth: tileconv.BD2}
+
+	entries, tiles, err := tileconv.BuildOAM(src, tileconv.NES8x8Sprites, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := tileconv.SpriteShape{Cols: 1, Rows: 1}
+	want := []tileconv.OAMEntry{
+		{X: 0, Y: 0, Shape: one, Tile: 0},
+		{X: 8, Y: 0, Shape: one, Tile: 0, HFlip: true},
+	}
+	verify(t, "bad entries", entries, want)
+	verify(t, "bad tiles", tiles, encodeAll(t, src.SubImage(
+		image.Rect(0, 0, 8, 8),
+	).(*image.Paletted), c))
+
+	// Without flips, both tiles are stored.
+	hw := tileconv.NES8x8Sprites
+	hw.Flips = false
+	entries, tiles, err = tileconv.BuildOAM(src, hw, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want[1].Tile, want[1].HFlip = 1, false
+	verify(t, "bad unflipped entries", entries, want)
+	verify(t, "bad unflipped tiles", tiles, encodeAll(t, src, c))
+}
+
+func TestBuildOAM_MegaDrive(t *testing.T) {
+	// A 20x20 square that is offset from the tile grid, which fits in a
+	// single 3x3 sprite.
+	src := image.NewPaletted(image.Rect(0, 0, 32, 32), make(color.Palette, 16))
+	for y := 5; y < 25; y++ {
+		for x := 3; x < 23; x++ {
+			src.SetColorIndex(x, y, uint8(x+y)&15|1)
+		}
+	}
+	c := tileconv.Packed{BitDepth: tileconv.BD4}
+
+	entries, tiles, err := tileconv.BuildOAM(
+		src, tileconv.MegaDriveSprites, c,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tileconv.OAMEntry{
+		{X: 3, Y: 5, Shape: tileconv.SpriteShape{Cols: 3, Rows: 3}},
+	}
+	verify(t, "bad entries", entries, want)
+
+	// The tiles are stored column by column.
+	var expected []byte
+	for col := 0; col < 3; col++ {
+		for row := 0; row < 3; row++ {
+			buf := make([]byte, c.Size())
+			c.Encode(src, 3+col*8, 5+row*8, buf)
+			expected = append(expected, buf...)
+		}
+	}
+	verify(t, "bad tiles", tiles, expected)
+}
+
+func TestBuildOAM_Shapes(t *testing.T) {
+	// A 32x8 bar fits in one 4x1 GBA sprite, and a 16x16 block needs two
+	// NES 8x16 sprites.
+	bar := image.NewPaletted(image.Rect(0, 0, 32, 8), make(color.Palette, 2))
+	for i := range bar.Pix {
+		bar.Pix[i] = 1
+	}
+	c := tileconv.Packed{BitDepth: tileconv.BD1}
+	entries, _, err := tileconv.BuildOAM(bar, tileconv.GBASprites, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify(t, "bad GBA entries", entries, []tileconv.OAMEntry{
+		{Shape: tileconv.SpriteShape{Cols: 4, Rows: 1}},
+	})
+
+	block := image.NewPaletted(
+		image.Rect(0, 0, 16, 16), make(color.Palette, 2),
+	)
+	for i := range block.Pix {
+		block.Pix[i] = 1
+	}
+	entries, _, err = tileconv.BuildOAM(block, tileconv.NES8x16Sprites, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tall := tileconv.SpriteShape{Cols: 1, Rows: 2}
+	verify(t, "bad NES entries", entries, []tileconv.OAMEntry{
+		{X: 0, Y: 0, Shape: tall},
+		{X: 8, Y: 0, Shape: tall},
+	})
+}
+
+func TestBuildOAM_Errors(t *testing.T) {
+	src := image.NewPaletted(image.Rect(0, 0, 8, 8), make(color.Palette, 2))
+	c := tileconv.Packed{BitDepth: tileconv.BD1}
+	bad := []tileconv.SpriteHardware{
+		{},
+		{Shapes: []tileconv.SpriteShape{{Cols: 0, Rows: 1}}},
+	}
+	for _, hw := range bad {
+		if _, _, err := tileconv.BuildOAM(src, hw, c); err == nil {
+			t.Errorf("no error for %+v", hw)
+		}
+	}
+}
+
+// checkCovered checks that every pixel of the frame that is not color 0
+// is inside one of the sprites.
+func checkCovered(
+	t *testing.T, src *image.Paletted, entries []tileconv.OAMEntry,
+) {
+	t.Helper()
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			if src.ColorIndexAt(x, y) == 0 {
+				continue
+			}
+			covered := false
+			for _, e := range entries {
+				r := image.Rect(
+					e.X, e.Y, e.X+e.Shape.Cols*8, e.Y+e.Shape.Rows*8,
+				)
+				covered = covered || image.Pt(x, y).In(r)
+			}
+			if !covered {
+				t.Fatalf("pixel at %v,%v is not covered", x, y)
+			}
+		}
+	}
+}
+
+func TestBuildOAM_Fewest(t *testing.T) {
+	// Choosing the sprite that covers the most pixels first would need 3
+	// sprites for this, but 2 are enough.
+	src := image.NewPaletted(image.Rect(0, 0, 48, 48), make(color.Palette, 2))
+	for _, r := range []image.Rectangle{
+		image.Rect(38, 0, 41, 1),
+		image.Rect(20, 37, 36, 41),
+		image.Rect(6, 14, 16, 25),
+	} {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				src.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	c := tileconv.Packed{BitDepth: tileconv.BD1}
+
+	entries, _, err := tileconv.BuildOAM(src, tileconv.MegaDriveSprites, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("bad sprite count: want 2, got %v: %+v",
+			len(entries), entries)
+	}
+	checkCovered(t, src, entries)
+}
+
+func TestBuildOAM_SNES(t *testing.T) {
+	// A 16x16 block and a separate 8x8 block, with 8x8 and 16x16 sprites.
+	src := image.NewPaletted(image.Rect(0, 0, 48, 16), make(color.Palette, 4))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			src.SetColorIndex(x, y, uint8(1+(x/8+y/8*2)%3))
+		}
+		if y < 8 {
+			for x := 32; x < 40; x++ {
+				src.SetColorIndex(x, y, uint8(1+(x+y)%3))
+			}
+		}
+	}
+	c := tileconv.TilePlanar{BitDepth: tileconv.BD2}
+
+	entries, tiles, err := tileconv.BuildOAM(src, tileconv.SNESSprites[0], c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tileconv.OAMEntry{
+		{X: 0, Y: 0, Shape: tileconv.SpriteShape{Cols: 2, Rows: 2}, Tile: 0},
+		{X: 32, Y: 0, Shape: tileconv.SpriteShape{Cols: 1, Rows: 1}, Tile: 2},
+	}
+	verify(t, "bad entries", entries, want)
+
+	// The 16x16 sprite uses tiles 0, 1, 16 and 17 of the 16-tile-wide
+	// grid, and the unused tiles in between are blank.
+	tile := func(x, y int) []byte {
+		buf := make([]byte, c.Size())
+		c.Encode(src, x, y, buf)
+		return buf
+	}
+	blank := make([]byte, c.Size())
+	var expected []byte
+	for i := 0; i < 18; i++ {
+		switch i {
+		case 0, 1:
+			expected = append(expected, tile(i*8, 0)...)
+		case 2:
+			expected = append(expected, tile(32, 0)...)
+		case 16, 17:
+			expected = append(expected, tile((i-16)*8, 8)...)
+		default:
+			expected = append(expected, blank...)
+		}
+	}
+	verify(t, "bad tiles", tiles, expected)
+}
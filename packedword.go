@@ -0,0 +1,112 @@
+package tileconv
+
+// PackedWord is a Codec that encodes each tile as a packed-pixel image,
+// like Packed, but with the pixels grouped into multi-byte words that
+// have a configurable byte order and pixel order.
+//
+// The pixels of the tile are packed into a sequence of words, one after
+// the other in row-major order, and each word is then stored as bytes.
+//
+// Some examples of formats that use this codec (with 2 bits per pixel
+// and 16-bit little-endian words, i.e. one word per row):
+//
+//   - Neo Geo Pocket (Color): leftmost pixel in the highest bits.
+//   - Virtual Boy: leftmost pixel in the lowest bits (LowFirst).
+//
+// The two differ even though both use little-endian words: the NGP
+// stores pixel 0 of a row in bits 15-14 of its word, so the first byte
+// of each row holds pixels 4-7, while the Virtual Boy stores pixel 0 in
+// bits 1-0, so the first byte holds pixels 0-3.
+//
+// Note that the WonderSwan does not need this codec, as its 2bpp and
+// WonderSwan Color 4bpp planar formats are RowPlanar, while the
+// WonderSwan Color 4bpp packed format is Packed.
+type PackedWord struct {
+	BitDepth BitDepth
+
+	// WordSize is the number of bytes in each word (default 2). It must
+	// divide the size of a tile, so 1, 2, 4 and 8 are always valid.
+	WordSize int
+
+	// BigEndian makes the words be stored with the most significant
+	// byte first; otherwise, the least significant byte is first.
+	BigEndian bool
+
+	// LowFirst makes the leftmost pixel be stored in the lowest bits of
+	// each word, and the following pixels in increasingly higher bits;
+	// otherwise, the leftmost pixel is stored in the highest bits.
+	LowFirst bool
+}
+
+var _ Codec = PackedWord{}
+
+// Size implements Codec, returning the size of a tile.
+func (c PackedWord) Size() int {
+	return c.BitDepth.BytesPerTile()
+}
+
+func (c PackedWord) wordSize() int {
+	if c.WordSize > 0 {
+		return c.WordSize
+	}
+	return 2
+}
+
+// bitPos returns the byte index and bit mask of the given bit (where
+// 0 is the lowest) of the color of the given pixel of the tile.
+func (c PackedWord) bitPos(pixel, bit int) (int, byte) {
+	bpp, ws := c.BitDepth.Planes(), c.wordSize()
+	wordBits := ws * 8
+
+	var word, pos int
+	if c.LowFirst {
+		s := pixel*bpp + bit
+		word, pos = s/wordBits, s%wordBits
+	} else {
+		s := pixel*bpp + (bpp - 1 - bit)
+		word, pos = s/wordBits, wordBits-1-s%wordBits
+	}
+
+	b := pos / 8
+	if c.BigEndian {
+		b = ws - 1 - b
+	}
+	return word*ws + b, 1 << (pos % 8)
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c PackedWord) Encode(src SourceImage, x, y int, dst []byte) {
+	bpp := c.BitDepth.Planes()
+	size := c.Size()
+	for i := 0; i < size; i++ {
+		dst[i] = 0
+	}
+	for iy := 0; iy < 8; iy++ {
+		for ix := 0; ix < 8; ix++ {
+			color := src.ColorIndexAt(x+ix, y+iy)
+			for b := 0; b < bpp; b++ {
+				if color&(1<<b) != 0 {
+					i, mask := c.bitPos(iy*8+ix, b)
+					dst[i] |= mask
+				}
+			}
+		}
+	}
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c PackedWord) Decode(src []byte, dst DestImage, x, y int) {
+	bpp := c.BitDepth.Planes()
+	for iy := 0; iy < 8; iy++ {
+		for ix := 0; ix < 8; ix++ {
+			color := uint8(0)
+			for b := 0; b < bpp; b++ {
+				i, mask := c.bitPos(iy*8+ix, b)
+				if src[i]&mask != 0 {
+					color |= 1 << b
+				}
+			}
+			dst.SetColorIndex(x+ix, y+iy, color)
+		}
+	}
+}
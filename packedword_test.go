@@ -0,0 +1,124 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// This uses the same pixel data as for the RowPlanar tests.
+var packedWordTestPix = [][]uint8{
+	{0x01, 0x94, 0xFD, 0xC2, 0xFA, 0x2F, 0xFC, 0xC0},
+	{0x41, 0xD3, 0xFF, 0x12, 0x04, 0x5B, 0x73, 0xC8},
+	{0x6E, 0x4F, 0xF9, 0x5F, 0xF6, 0x62, 0xA5, 0xEE},
+	{0xE8, 0x2A, 0xBD, 0xF4, 0x4A, 0x2D, 0x0B, 0x75},
+	{0xFB, 0x18, 0x0D, 0xAF, 0x48, 0xA7, 0x9E, 0xE0},
+	{0xB1, 0x0D, 0x39, 0x46, 0x51, 0x85, 0x0F, 0xD4},
+	{0xA1, 0x78, 0x89, 0x2E, 0xE2, 0x85, 0xEC, 0xE1},
+	{0x51, 0x14, 0x55, 0x78, 0x08, 0x75, 0xD6, 0x4E},
+}
+
+func TestPackedWordSize(t *testing.T) {
+	for bd := tileconv.BD1; bd <= tileconv.BD8; bd++ {
+		c := tileconv.PackedWord{BitDepth: bd}
+		verify(t, "bad size", c.Size(), bd.BytesPerTile())
+	}
+}
+
+func TestPackedWordEncode(t *testing.T) {
+	// Neo Geo Pocket: little-endian, leftmost pixel in the high bits.
+	runCodecEncodeTests(
+		t, "NGP", tileconv.PackedWord{BitDepth: tileconv.BD2},
+		packedWordTestPix, []byte{
+			0b10110000, 0b01000110,
+			0b00111100, 0b01111110,
+			0b10100110, 0b10110111,
+			0b10011101, 0b00100100,
+			0b00111000, 0b11000111,
+			0b01011100, 0b01010110,
+			0b10010001, 0b01000110,
+			0b00011010, 0b01000100,
+		},
+	)
+
+	// Virtual Boy: little-endian, leftmost pixel in the low bits.
+	runCodecEncodeTests(
+		t, "VB",
+		tileconv.PackedWord{BitDepth: tileconv.BD2, LowFirst: true},
+		packedWordTestPix, []byte{
+			0b10010001, 0b00001110,
+			0b10111101, 0b00111100,
+			0b11011110, 0b10011010,
+			0b00011000, 0b01110110,
+			0b11010011, 0b00101100,
+			0b10010101, 0b00110101,
+			0b10010001, 0b01000110,
+			0b00010001, 0b10100100,
+		},
+	)
+}
+
+func TestPackedWordDecode(t *testing.T) {
+	runCodecDecodeTests(
+		t, "NGP", tileconv.PackedWord{BitDepth: tileconv.BD2},
+		[]byte{
+			0b10110000, 0b01000110,
+			0b00111100, 0b01111110,
+			0b10100110, 0b10110111,
+			0b10011101, 0b00100100,
+			0b00111000, 0b11000111,
+			0b01011100, 0b01010110,
+			0b10010001, 0b01000110,
+			0b00011010, 0b01000100,
+		},
+		pixBits(2, packedWordTestPix),
+	)
+
+	runCodecDecodeTests(
+		t, "VB",
+		tileconv.PackedWord{BitDepth: tileconv.BD2, LowFirst: true},
+		[]byte{
+			0b10010001, 0b00001110,
+			0b10111101, 0b00111100,
+			0b11011110, 0b10011010,
+			0b00011000, 0b01110110,
+			0b11010011, 0b00101100,
+			0b10010101, 0b00110101,
+			0b10010001, 0b01000110,
+			0b00010001, 0b10100100,
+		},
+		pixBits(2, packedWordTestPix),
+	)
+}
+
+// TestPackedWordAsPacked tests that PackedWord gives the same result as
+// Packed when the words are stored in the same order as Packed uses,
+// and that little-endian words are the same with the bytes swapped.
+func TestPackedWordAsPacked(t *testing.T) {
+	src := newTestImage(0, 0, packedWordTestPix)
+	for bd := tileconv.BD1; bd <= tileconv.BD8; bd++ {
+		want := make([]byte, bd.BytesPerTile())
+		tileconv.Packed{BitDepth: bd}.Encode(src, 0, 0, want)
+
+		check := func(name string, c tileconv.PackedWord, want []byte) {
+			t.Helper()
+			got := make([]byte, c.Size())
+			c.Encode(src, 0, 0, got)
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s BD%v:\nwant: %v\n got: %v", name, bd, want, got)
+			}
+		}
+
+		check("bytes", tileconv.PackedWord{BitDepth: bd, WordSize: 1}, want)
+		check("big-endian", tileconv.PackedWord{
+			BitDepth: bd, WordSize: 4, BigEndian: true,
+		}, want)
+
+		swapped := make([]byte, len(want))
+		for i := 0; i < len(want); i += 2 {
+			swapped[i], swapped[i+1] = want[i+1], want[i]
+		}
+		check("little-endian", tileconv.PackedWord{BitDepth: bd}, swapped)
+	}
+}
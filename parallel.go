@@ -0,0 +1,150 @@
+package tileconv
+
+import (
+	"context"
+	"image"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// EncodeParallel does the same as Encode, but splits the work between
+// the given number of goroutines (or runtime.GOMAXPROCS(0), if that is
+// not positive), each of which encodes one row of tiles at a time. The
+// output is still written in order, and is the same as from Encode.
+//
+// Both the codec and the image must allow being used concurrently, as
+// the codecs in this package and *image.Paletted do.
+//
+// If ctx is canceled, this stops as soon as possible and returns the
+// error from ctx, after having written only some of the rows of tiles.
+func EncodeParallel(
+	ctx context.Context, src image.PalettedImage, dst io.Writer, c Codec,
+	workers int,
+) error {
+	t := newTileRows(src.Bounds(), c)
+	workers = parallelWorkers(workers)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Each row has its own result channel, to let them be written in
+	// order, while ahead limits how many can be waiting to be written.
+	results := make([]chan []byte, t.rows)
+	for i := range results {
+		results[i] = make(chan []byte, 1)
+	}
+	ahead := make(chan struct{}, workers*2)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	runParallel(ctx, &wg, t.rows, workers, ahead, func(row int) {
+		buf := make([]byte, t.rowSize())
+		t.encodeRow(src, c, row, buf)
+		results[row] <- buf
+	})
+
+	for row := range results {
+		// Check this first, since select picks randomly if both are ready.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		select {
+		case buf := <-results[row]:
+			if _, err := dst.Write(buf); err != nil {
+				return err
+			}
+			<-ahead
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// DecodeParallel does the same as Decode, but splits the work between
+// the given number of goroutines (or runtime.GOMAXPROCS(0), if that is
+// not positive), each of which decodes one row of tiles at a time. The
+// resulting image is the same as from Decode.
+//
+// The codec must allow being used concurrently, as the codecs in this
+// package do.
+//
+// If ctx is canceled, this stops as soon as possible and returns the
+// error from ctx, after having decoded only some of the rows of tiles.
+func DecodeParallel(
+	ctx context.Context, src []byte, dst *image.Paletted, c Codec,
+	workers int,
+) error {
+	t := newTileRows(dst.Bounds(), c)
+	workers = parallelWorkers(workers)
+
+	var wg sync.WaitGroup
+	fed := runParallel(ctx, &wg, t.rowsIn(len(src)), workers, nil,
+		func(row int) {
+			t.decodeRow(src, dst, c, row)
+		},
+	)
+	wg.Wait()
+
+	if *fed < t.rowsIn(len(src)) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+func parallelWorkers(workers int) int {
+	if workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return workers
+}
+
+// runParallel starts the given number of worker goroutines (and one to
+// feed them), which call fn for each row, from 0 to rows-1, until ctx
+// is canceled. If ahead is not nil, a value is sent to it before each
+// row is started, to allow limiting how far ahead the workers can get.
+//
+// The goroutines are added to wg, and the returned value is only valid
+// after they are done; it is the number of rows that were started.
+func runParallel(
+	ctx context.Context, wg *sync.WaitGroup, rows, workers int,
+	ahead chan struct{}, fn func(row int),
+) *int {
+	jobs := make(chan int)
+	fed := new(int)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for row := 0; row < rows; row++ {
+			if ahead != nil {
+				select {
+				case ahead <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- row:
+				*fed++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				fn(row)
+			}
+		}()
+	}
+
+	return fed
+}
@@ -0,0 +1,189 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func parallelTestCodecs() []tileconv.Codec {
+	return []tileconv.Codec{
+		tileconv.Packed{BitDepth: tileconv.BD3},
+		tileconv.RowPlanar{BitDepth: tileconv.BD2},
+		tileconv.TilePlanar{BitDepth: tileconv.BD4},
+		tileconv.TileRowPairPlanar{BitDepth: tileconv.BD5},
+		tileconv.WordPlanar{BitDepth: tileconv.BD4},
+		tileconv.C64Sprite{Multicolor: true, WidePixels: true},
+	}
+}
+
+func TestEncodeParallel(t *testing.T) {
+	// This size leaves partial tiles at the right and bottom edges.
+	src := newRandomSheet(200, 150, 1)
+	for _, c := range parallelTestCodecs() {
+		var want bytes.Buffer
+		if err := tileconv.Encode(src, &want, c); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, workers := range []int{0, 1, 3, 64} {
+			var got bytes.Buffer
+			err := tileconv.EncodeParallel(
+				context.Background(), src, &got, c, workers,
+			)
+			if err != nil {
+				t.Fatalf("%T %v: unexpected error: %v", c, workers, err)
+			}
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Errorf("%T %v: bad encoded data", c, workers)
+			}
+		}
+	}
+}
+
+func TestDecodeParallel(t *testing.T) {
+	for _, c := range parallelTestCodecs() {
+		// Leave the data ending in the middle of a row of tiles.
+		tw, _ := tileconv.TileSize(c)
+		data := make([]byte, (200/tw*7+3)*c.Size()+1)
+		rand.New(rand.NewSource(2)).Read(data)
+
+		want := newRandomSheet(200, 150, 3)
+		tileconv.Decode(data, want, c)
+		for _, workers := range []int{0, 1, 3, 64} {
+			got := newRandomSheet(200, 150, 3)
+			err := tileconv.DecodeParallel(
+				context.Background(), data, got, c, workers,
+			)
+			if err != nil {
+				t.Fatalf("%T %v: unexpected error: %v", c, workers, err)
+			}
+			if !bytes.Equal(got.Pix, want.Pix) {
+				t.Errorf("%T %v: bad decoded pixels", c, workers)
+			}
+		}
+	}
+}
+
+func TestParallel_Empty(t *testing.T) {
+	data := make([]byte, 256)
+	for _, r := range []image.Rectangle{
+		image.Rect(0, 0, 0, 0),
+		image.Rect(0, 0, 0, 16),
+		image.Rect(0, 0, 16, 0),
+	} {
+		img := image.NewPaletted(r, newTestPalette())
+		for _, c := range parallelTestCodecs() {
+			var buf bytes.Buffer
+			if err := tileconv.Encode(img, &buf, c); err != nil {
+				t.Errorf("%T %v: encode error: %v", c, r, err)
+			}
+			err := tileconv.EncodeParallel(
+				context.Background(), img, &buf, c, 3,
+			)
+			if err != nil {
+				t.Errorf("%T %v: parallel encode error: %v", c, r, err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("%T %v: encoded %v bytes", c, r, buf.Len())
+			}
+
+			tileconv.Decode(data, img, c)
+			err = tileconv.DecodeParallel(
+				context.Background(), data, img, c, 3,
+			)
+			if err != nil {
+				t.Errorf("%T %v: parallel decode error: %v", c, r, err)
+			}
+		}
+	}
+}
+
+// cancelWriter cancels a context when it is first written to.
+type cancelWriter struct {
+	cancel context.CancelFunc
+	writes int
+}
+
+func (w *cancelWriter) Write(b []byte) (int, error) {
+	w.writes++
+	w.cancel()
+	return len(b), nil
+}
+
+func TestParallel_Cancel(t *testing.T) {
+	src := newRandomSheet(256, 256, 1)
+	c := tileconv.RowPlanar{BitDepth: tileconv.BD2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &cancelWriter{cancel: cancel}
+	err := tileconv.EncodeParallel(ctx, src, w, c, 4)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong encode error: want context.Canceled, got %v", err)
+	}
+	if w.writes != 1 {
+		t.Errorf("encoding was not stopped: got %v writes", w.writes)
+	}
+
+	err = tileconv.DecodeParallel(ctx, make([]byte, 4096), src, c, 4)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong decode error: want context.Canceled, got %v", err)
+	}
+}
+
+func TestEncodeParallel_Error(t *testing.T) {
+	src := newRandomSheet(64, 64, 1)
+	c := tileconv.RowPlanar{BitDepth: tileconv.BD2}
+	w := &ErrWriter{Remain: 3 * 8 * c.Size()}
+	err := tileconv.EncodeParallel(context.Background(), src, w, c, 4)
+	if err != w {
+		t.Errorf("wrong error:\nwant: %#v\n got: %#v", w, err)
+	}
+}
+
+func BenchmarkEncodeParallel(b *testing.B) {
+	// 4096 tiles.
+	src := newRandomSheet(512, 512, 1)
+	c := tileconv.Packed{BitDepth: tileconv.BD4}
+	b.Run("Sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = tileconv.Encode(src, io.Discard, c)
+		}
+	})
+	for _, workers := range []int{2, 4, 8} {
+		b.Run(fmt.Sprint("Workers", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = tileconv.EncodeParallel(
+					context.Background(), src, io.Discard, c, workers,
+				)
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeParallel(b *testing.B) {
+	dst := image.NewPaletted(image.Rect(0, 0, 512, 512), newTestPalette())
+	c := tileconv.Packed{BitDepth: tileconv.BD4}
+	data := make([]byte, 64*64*c.Size())
+	rand.New(rand.NewSource(2)).Read(data)
+	b.Run("Sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tileconv.Decode(data, dst, c)
+		}
+	})
+	for _, workers := range []int{2, 4, 8} {
+		b.Run(fmt.Sprint("Workers", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = tileconv.DecodeParallel(
+					context.Background(), data, dst, c, workers,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package tileconv
+
+// PCESprite is a Codec for PC Engine (TurboGrafx-16) sprite patterns.
+//
+// Each pattern is 16x16 pixels with 4 bits per pixel, stored as four
+// planes of 16 rows each, with all the rows of a plane stored before
+// the next plane. Each row is a 16-bit little-endian word, with the
+// leftmost pixel in the highest bit, for a total of 128 bytes.
+//
+// Background tiles instead use TileRowPairPlanar with BD4.
+type PCESprite struct{}
+
+var _ Codec = PCESprite{}
+var _ TileSizer = PCESprite{}
+
+const pceSpritePlanes = 4
+
+// Size implements Codec, returning the size of a tile.
+func (c PCESprite) Size() int {
+	return pceSpritePlanes * 16 * 2
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c PCESprite) TileSize() (w, h int) {
+	return 16, 16
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c PCESprite) Encode(src SourceImage, x, y int, dst []byte) {
+	for iy := 0; iy < 16; iy++ {
+		var words [pceSpritePlanes]uint16
+		for ix := 0; ix < 16; ix++ {
+			color := src.ColorIndexAt(x+ix, y+iy)
+			for p := 0; p < pceSpritePlanes; p++ {
+				words[p] = (words[p] << 1) | uint16(color&1)
+				color >>= 1
+			}
+		}
+		for p := 0; p < pceSpritePlanes; p++ {
+			i := (p*16 + iy) * 2
+			dst[i], dst[i+1] = byte(words[p]), byte(words[p]>>8)
+		}
+	}
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c PCESprite) Decode(src []byte, dst DestImage, x, y int) {
+	for iy := 0; iy < 16; iy++ {
+		row := [16]uint8{}
+		for p := 0; p < pceSpritePlanes; p++ {
+			i := (p*16 + iy) * 2
+			d := uint16(src[i]) | uint16(src[i+1])<<8
+			for ix := 16 - 1; ix >= 0; ix-- {
+				row[ix] |= uint8(d&1) << p
+				d >>= 1
+			}
+		}
+		for ix := 0; ix < 16; ix++ {
+			dst.SetColorIndex(x+ix, y+iy, row[ix])
+		}
+	}
+}
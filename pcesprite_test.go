@@ -0,0 +1,73 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestPCESpriteSize(t *testing.T) {
+	c := tileconv.PCESprite{}
+	verify(t, "bad size", c.Size(), 128)
+	w, h := tileconv.TileSize(c)
+	verify(t, "bad tile size", [2]int{w, h}, [2]int{16, 16})
+}
+
+func TestPCESpriteEncode(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 16, 16), newTestPalette())
+	// Row 0 counts through all the colors, with junk in the high bits.
+	for x := 0; x < 16; x++ {
+		img.SetColorIndex(x, 0, uint8(x)|0xA0)
+	}
+	// Row 15 has color 9 in the leftmost and rightmost pixels.
+	img.SetColorIndex(0, 15, 9)
+	img.SetColorIndex(15, 15, 9)
+
+	c := tileconv.PCESprite{}
+	got := make([]byte, c.Size()+1)
+	got[c.Size()] = 0x5A
+	c.Encode(img, 0, 0, got)
+
+	want := make([]byte, c.Size()+1)
+	want[c.Size()] = 0x5A
+	// Each row word is little-endian; pixel 0 is the highest bit.
+	put := func(plane, row int, v uint16) {
+		want[(plane*16+row)*2] = byte(v)
+		want[(plane*16+row)*2+1] = byte(v >> 8)
+	}
+	put(0, 0, 0b01010101_01010101)
+	put(1, 0, 0b00110011_00110011)
+	put(2, 0, 0b00001111_00001111)
+	put(3, 0, 0b00000000_11111111)
+	put(0, 15, 0b10000000_00000001)
+	put(3, 15, 0b10000000_00000001)
+	verify(t, "bad encoded data", got, want)
+
+	dec := image.NewPaletted(img.Rect, img.Palette)
+	c.Decode(got, dec, 0, 0)
+	for i := range img.Pix {
+		img.Pix[i] &= 0x0F
+	}
+	verify(t, "bad decoded pixels", dec.Pix, img.Pix)
+}
+
+func TestPCESpriteSheet(t *testing.T) {
+	pal := newTestPalette()
+	src := image.NewPaletted(image.Rect(0, 0, 32, 32), pal)
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i*11+i/7) & 0x0F
+	}
+
+	var buf bytes.Buffer
+	c := tileconv.PCESprite{}
+	if err := tileconv.Encode(src, &buf, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verify(t, "bad data length", buf.Len(), 4*128)
+
+	got := image.NewPaletted(src.Rect, pal)
+	tileconv.Decode(buf.Bytes(), got, c)
+	verify(t, "bad decoded pixels", got.Pix, src.Pix)
+}
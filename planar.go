@@ -0,0 +1,114 @@
+package tileconv
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// These helpers implement the table-driven fast paths of the planar
+// codecs. They work on whole rows of 8 pixels at a time, stored in a
+// uint64 with one color index per byte, and the leftmost pixel in the
+// lowest byte (matching the order of image.Paletted's Pix).
+
+// lowBits has the lowest bit of each byte of a row set.
+const lowBits = 0x0101010101010101
+
+// spreadBits maps a byte of plane data to a row that has the bit for
+// each pixel in the lowest bit of that pixel's byte.
+var spreadBits = func() (t [256]uint64) {
+	for d := range t {
+		for ix := 0; ix < 8; ix++ {
+			if d&(0x80>>ix) != 0 {
+				t[d] |= 1 << (ix * 8)
+			}
+		}
+	}
+	return t
+}()
+
+// planeByte returns the byte of plane data for the given plane of a
+// row, with the leftmost pixel in the highest bit.
+//
+// The multiplication gathers the lowest bit of each byte into the top
+// byte, in reverse order; no carries can occur since each product bit
+// ends up in a distinct position.
+func planeByte(row uint64, plane int) byte {
+	return byte((row >> plane & lowBits) * 0x8040201008040201 >> 56)
+}
+
+// readRow returns the color indexes of the 8 pixels of a row, starting
+// at x,y, reading them directly if src is an *image.Paletted that holds
+// the whole row.
+func readRow(src SourceImage, x, y int) uint64 {
+	if p, ok := src.(*image.Paletted); ok {
+		if image.Rect(x, y, x+8, y+1).In(p.Rect) {
+			i := p.PixOffset(x, y)
+			return binary.LittleEndian.Uint64(p.Pix[i : i+8])
+		}
+	}
+	var row uint64
+	for ix := 8 - 1; ix >= 0; ix-- {
+		row = row<<8 | uint64(src.ColorIndexAt(x+ix, y))
+	}
+	return row
+}
+
+// writeRow sets the color indexes of the 8 pixels of a row, starting at
+// x,y, writing them directly if dst is an *image.Paletted that holds
+// the whole row.
+func writeRow(dst DestImage, x, y int, row uint64) {
+	if p, ok := dst.(*image.Paletted); ok {
+		if image.Rect(x, y, x+8, y+1).In(p.Rect) {
+			i := p.PixOffset(x, y)
+			binary.LittleEndian.PutUint64(p.Pix[i:i+8], row)
+			return
+		}
+	}
+	for ix := 0; ix < 8; ix++ {
+		dst.SetColorIndex(x+ix, y, uint8(row))
+		row >>= 8
+	}
+}
+
+// planarTiles describes the layout of a planar codec's tiles, for use
+// by the BulkCodec implementations of those codecs.
+type planarTiles struct {
+	size, planes int
+
+	// mask has the color mask of the codec in each byte of a row.
+	mask uint64
+
+	// offset gives the offset in a tile of each [row][plane] byte.
+	offset [8][8]int
+}
+
+// encode implements BulkCodec.EncodeTiles for the described codec.
+func (l *planarTiles) encode(src *image.Paletted, x, y, n int, dst []byte) {
+	for iy := 0; iy < 8; iy++ {
+		pix := src.Pix[src.PixOffset(x, y+iy):]
+		offset := l.offset[iy][:l.planes]
+		for t := 0; t < n; t++ {
+			row := binary.LittleEndian.Uint64(pix[t*8:]) & l.mask
+			d := dst[t*l.size:]
+			for p, i := range offset {
+				d[i] = planeByte(row, p)
+			}
+		}
+	}
+}
+
+// decode implements BulkCodec.DecodeTiles for the described codec.
+func (l *planarTiles) decode(src []byte, dst *image.Paletted, x, y, n int) {
+	for iy := 0; iy < 8; iy++ {
+		pix := dst.Pix[dst.PixOffset(x, y+iy):]
+		offset := l.offset[iy][:l.planes]
+		for t := 0; t < n; t++ {
+			s := src[t*l.size:]
+			row := uint64(0)
+			for p, i := range offset {
+				row |= spreadBits[s[i]] << p
+			}
+			binary.LittleEndian.PutUint64(pix[t*8:], row&l.mask)
+		}
+	}
+}
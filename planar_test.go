@@ -0,0 +1,165 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// wrappedImage hides the *image.Paletted type from the codecs, to make
+// them use their generic code paths.
+type wrappedImage struct {
+	*image.Paletted
+}
+
+type planarCodec struct {
+	name   string
+	codec  tileconv.Codec
+	layout tileconv.BitLayout
+}
+
+// planarCodecs returns the planar codecs, with the BitLayout equivalents
+// that they are checked against.
+func planarCodecs(bd tileconv.BitDepth) []planarCodec {
+	return []planarCodec{
+		{
+			"RowPlanar", tileconv.RowPlanar{BitDepth: bd},
+			tileconv.BitLayout{BitDepth: bd, Order: "y,p,x"},
+		},
+		{
+			"TilePlanar", tileconv.TilePlanar{BitDepth: bd},
+			tileconv.BitLayout{BitDepth: bd, Order: "p,y,x"},
+		},
+		{
+			"TileRowPairPlanar", tileconv.TileRowPairPlanar{BitDepth: bd},
+			tileconv.BitLayout{
+				BitDepth: bd, Order: "g,y,p,x", PlaneGroup: 2,
+			},
+		},
+	}
+}
+
+func newRandomSheet(w, h int, seed int64) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, w, h), newTestPalette())
+	rand.New(rand.NewSource(seed)).Read(img.Pix)
+	return img
+}
+
+// TestPlanarFastPaths tests that the planar codecs give the same result
+// for *image.Paletted images as for other images, including for tiles
+// that are only partly inside the image.
+func TestPlanarFastPaths(t *testing.T) {
+	// This size leaves partial tiles at the right and bottom edges.
+	src := newRandomSheet(60, 44, 1)
+	data := make([]byte, 8*8*8*8)
+	rand.New(rand.NewSource(2)).Read(data)
+
+	for bd := tileconv.BD1; bd <= tileconv.BD8; bd++ {
+		for _, pc := range planarCodecs(bd) {
+			name, codec, layout := pc.name, pc.codec, pc.layout
+
+			var want, got, gotWrapped bytes.Buffer
+			if err := tileconv.Encode(src, &want, layout); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := tileconv.Encode(src, &got, codec); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			err := tileconv.Encode(wrappedImage{src}, &gotWrapped, codec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Errorf("%s BD%v: bad encoded data", name, bd)
+			}
+			if !bytes.Equal(gotWrapped.Bytes(), want.Bytes()) {
+				t.Errorf("%s BD%v: bad encoded data (wrapped)", name, bd)
+			}
+
+			wantImg := newRandomSheet(60, 44, 3)
+			tileconv.Decode(data, wantImg, layout)
+			gotImg := newRandomSheet(60, 44, 3)
+			tileconv.Decode(data, gotImg, codec)
+			wrapped := newRandomSheet(60, 44, 3)
+			decodeTiles(data, wrappedImage{wrapped}, codec)
+			if !bytes.Equal(gotImg.Pix, wantImg.Pix) {
+				t.Errorf("%s BD%v: bad decoded pixels", name, bd)
+			}
+			if !bytes.Equal(wrapped.Pix, wantImg.Pix) {
+				t.Errorf("%s BD%v: bad decoded pixels (wrapped)", name, bd)
+			}
+		}
+	}
+}
+
+// decodeTiles does the same as tileconv.Decode, but for any image.
+func decodeTiles(src []byte, dst wrappedImage, c tileconv.Codec) {
+	b := dst.Bounds()
+	sz := c.Size()
+	for y := b.Min.Y; y < b.Max.Y; y += 8 {
+		for x := b.Min.X; x < b.Max.X && len(src) >= sz; x += 8 {
+			c.Decode(src, dst, x, y)
+			src = src[sz:]
+		}
+	}
+}
+
+func BenchmarkPlanarEncode(b *testing.B) {
+	src := newRandomSheet(256, 256, 1)
+	for _, bd := range []tileconv.BitDepth{tileconv.BD2, tileconv.BD4} {
+		for _, pc := range planarCodecs(bd) {
+			codec := pc.codec
+			name := fmt.Sprintf("%s/BD%v", pc.name, bd)
+			b.Run(name+"/Paletted", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_ = tileconv.Encode(src, io.Discard, codec)
+				}
+			})
+			b.Run(name+"/Single", func(b *testing.B) {
+				single := singleCodec{codec}
+				for i := 0; i < b.N; i++ {
+					_ = tileconv.Encode(src, io.Discard, single)
+				}
+			})
+			b.Run(name+"/Image", func(b *testing.B) {
+				img := wrappedImage{src}
+				for i := 0; i < b.N; i++ {
+					_ = tileconv.Encode(img, io.Discard, codec)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkPlanarDecode(b *testing.B) {
+	dst := newRandomSheet(256, 256, 1)
+	data := make([]byte, 32*32*tileconv.BD4.BytesPerTile())
+	rand.New(rand.NewSource(2)).Read(data)
+	for _, bd := range []tileconv.BitDepth{tileconv.BD2, tileconv.BD4} {
+		for _, pc := range planarCodecs(bd) {
+			codec := pc.codec
+			name := fmt.Sprintf("%s/BD%v", pc.name, bd)
+			b.Run(name+"/Paletted", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					tileconv.Decode(data, dst, codec)
+				}
+			})
+			b.Run(name+"/Single", func(b *testing.B) {
+				single := singleCodec{codec}
+				for i := 0; i < b.N; i++ {
+					tileconv.Decode(data, dst, single)
+				}
+			})
+			b.Run(name+"/Image", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					decodeTiles(data, wrappedImage{dst}, codec)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tileconv
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// PlanePalette is the palette used for the images of single bit planes
+// that are returned by SplitPlanes.
+var PlanePalette = color.Palette{
+	color.Gray{0x00},
+	color.Gray{0xFF},
+}
+
+// SplitPlanes splits the color indexes of an image into the given
+// number of bit planes, returning a 1bpp image (using PlanePalette) for
+// each of them, where plane 0 holds the lowest bit of each color index.
+//
+// The returned images have the same bounds as the source image, and can
+// e.g. be encoded separately with any codec at BD1.
+func SplitPlanes(src image.PalettedImage, planes int) []*image.Paletted {
+	b := src.Bounds()
+	out := make([]*image.Paletted, planes)
+	for p := range out {
+		out[p] = image.NewPaletted(b, PlanePalette)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := src.ColorIndexAt(x, y)
+			for p, img := range out {
+				img.SetColorIndex(x, y, c>>p&1)
+			}
+		}
+	}
+	return out
+}
+
+// MergePlanes merges the given bit planes into a new image that uses
+// the given palette, where bit N of each color index is taken from the
+// lowest bit of the color index of the same pixel in planes[N]. This is
+// the reverse of SplitPlanes.
+//
+// A plane can be nil, in which case that bit is always 0. The other
+// planes must all be the same size, but can have different bounds; the
+// pixels are matched up relative to the top-left corner of each plane.
+// The new image is at 0,0. There can be at most 8 planes.
+func MergePlanes(
+	planes []image.PalettedImage, pal color.Palette,
+) (*image.Paletted, error) {
+	if len(planes) > 8 {
+		return nil, errors.New("too many bit planes")
+	}
+
+	var size image.Point
+	for _, p := range planes {
+		if p == nil {
+			continue
+		}
+		s := p.Bounds().Size()
+		if size == (image.Point{}) {
+			size = s
+		} else if s != size {
+			return nil, errors.New("bit planes have different sizes")
+		}
+	}
+
+	img := image.NewPaletted(image.Rectangle{Max: size}, pal)
+	for bit, p := range planes {
+		if p == nil {
+			continue
+		}
+		min := p.Bounds().Min
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				c := p.ColorIndexAt(min.X+x, min.Y+y) & 1
+				img.Pix[img.PixOffset(x, y)] |= c << bit
+			}
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,76 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestSplitPlanes(t *testing.T) {
+	src := image.NewPaletted(image.Rect(2, 3, 5, 4), newTestPalette())
+	copy(src.Pix, []uint8{0b101, 0b010, 0b111})
+
+	planes := tileconv.SplitPlanes(src, 4)
+	verify(t, "bad plane count", len(planes), 4)
+	want := [][]uint8{{1, 0, 1}, {0, 1, 1}, {1, 0, 1}, {0, 0, 0}}
+	for i, p := range planes {
+		verify(t, "bad plane bounds", p.Rect, src.Rect)
+		verify(t, "bad plane pixels", p.Pix, want[i])
+	}
+}
+
+func TestMergePlanes(t *testing.T) {
+	src := newRandomSheet(24, 16, 1)
+	for i := range src.Pix {
+		src.Pix[i] &= 0x0F
+	}
+	planes := tileconv.SplitPlanes(src, 4)
+
+	// Each plane can be encoded with any codec, as 1bpp tiles.
+	var sources []image.PalettedImage
+	for _, p := range planes {
+		var buf bytes.Buffer
+		c := tileconv.TilePlanar{BitDepth: tileconv.BD1}
+		if err := tileconv.Encode(p, &buf, c); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Decode at a different position, to check that it is ignored.
+		dec := image.NewPaletted(image.Rect(8, 8, 32, 24), newTestPalette())
+		tileconv.Decode(buf.Bytes(), dec, c)
+		sources = append(sources, dec)
+	}
+
+	got, err := tileconv.MergePlanes(sources, src.Palette)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verify(t, "bad merged image", got, src)
+
+	// A missing plane gives zero bits.
+	sources[1] = nil
+	got, err = tileconv.MergePlanes(sources, src.Palette)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range src.Pix {
+		src.Pix[i] &^= 0b10
+	}
+	verify(t, "bad merged image with missing plane", got, src)
+}
+
+func TestMergePlanes_Errors(t *testing.T) {
+	a := image.NewPaletted(image.Rect(0, 0, 8, 8), nil)
+	b := image.NewPaletted(image.Rect(0, 0, 8, 16), nil)
+	if _, err := tileconv.MergePlanes(
+		[]image.PalettedImage{a, b}, nil,
+	); err == nil {
+		t.Errorf("missing error for different sizes")
+	}
+
+	planes := make([]image.PalettedImage, 9)
+	if _, err := tileconv.MergePlanes(planes, nil); err == nil {
+		t.Errorf("missing error for too many planes")
+	}
+}
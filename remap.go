@@ -0,0 +1,224 @@
+package tileconv
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"sort"
+)
+
+// Remap is a lookup table that maps color indexes to other color
+// indexes, e.g. to make a tileset use other palette entries, or to make
+// it fit in a smaller bit depth.
+//
+// Color indexes that are not in the table are not mapped to anything,
+// so trying to remap them is an error.
+type Remap map[uint8]uint8
+
+// OffsetRemap returns a Remap that adds the given offset to each color
+// index, leaving out the color indexes that would end up outside 0-255.
+// An offset of 0 gives a Remap that maps each color index to itself,
+// which can be used as a base for changing only some of them.
+func OffsetRemap(offset int) Remap {
+	r := Remap{}
+	for c := 0; c < 256; c++ {
+		if n := c + offset; n >= 0 && n < 256 {
+			r[uint8(c)] = uint8(n)
+		}
+	}
+	return r
+}
+
+// CompactRemap returns a Remap that maps the color indexes that are
+// used by the image to the lowest color indexes, keeping their order,
+// e.g. to let an image that uses only 4 colors be encoded at BD2.
+func CompactRemap(src image.PalettedImage) Remap {
+	r := Remap{}
+	for i, c := range usedColors(src) {
+		r[c] = uint8(i)
+	}
+	return r
+}
+
+// Inverse returns the Remap that undoes this one, or an error if two
+// color indexes are mapped to the same color index.
+func (r Remap) Inverse() (Remap, error) {
+	inv := Remap{}
+	for _, c := range r.keys() {
+		to := r[c]
+		if from, ok := inv[to]; ok {
+			return nil, fmt.Errorf(
+				"colors %v and %v are both remapped to %v", from, c, to,
+			)
+		}
+		inv[to] = c
+	}
+	return inv, nil
+}
+
+// Check returns an error if remapping the given color indexes would
+// lose any colors, because one of them is not in the table, is mapped
+// to a color index that is not below max, or is mapped to the same
+// color index as another of them.
+func (r Remap) Check(colors []uint8, max int) error {
+	seen := map[uint8]uint8{}
+	for _, c := range colors {
+		to, ok := r[c]
+		if !ok {
+			return fmt.Errorf("color %v is not remapped", c)
+		}
+		if int(to) >= max {
+			return fmt.Errorf(
+				"color %v is remapped to %v, which is not below %v",
+				c, to, max,
+			)
+		}
+		if from, ok := seen[to]; ok && from != c {
+			return fmt.Errorf(
+				"colors %v and %v are both remapped to %v", from, c, to,
+			)
+		}
+		seen[to] = c
+	}
+	return nil
+}
+
+// keys returns the color indexes in the table, in ascending order.
+func (r Remap) keys() []uint8 {
+	keys := make([]uint8, 0, len(r))
+	for c := range r {
+		keys = append(keys, c)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// CheckEncode returns an error if using EncodeRemapped with this Remap
+// would lose any colors (see Check), with the remapped color indexes
+// having to fit in the given bit depth.
+//
+// If the image size is not a multiple of the codec's tile size, color 0
+// is checked as well, since that is what Encode uses for the padding.
+func (r Remap) CheckEncode(
+	src image.PalettedImage, c Codec, bd BitDepth,
+) error {
+	colors := usedColors(src)
+	w, h := TileSize(c)
+	b := src.Bounds()
+	padded := b.Dx()%w != 0 || b.Dy()%h != 0
+	if padded && (len(colors) == 0 || colors[0] != 0) {
+		colors = append([]uint8{0}, colors...)
+	}
+	return r.Check(colors, bd.Colors())
+}
+
+// EncodeRemapped does the same as Encode, but remaps the color indexes
+// of the image before encoding them.
+//
+// If that would lose any colors (see CheckEncode), an error is returned
+// without encoding anything.
+func EncodeRemapped(
+	src image.PalettedImage, dst io.Writer, c Codec, r Remap, bd BitDepth,
+) error {
+	if err := r.CheckEncode(src, c, bd); err != nil {
+		return err
+	}
+	return Encode(src, dst, newRemapCodec(c, r))
+}
+
+// DecodeRemapped does the same as Decode, but remaps the decoded color
+// indexes before storing them in the image.
+//
+// If that loses any colors (see Remap.Check), with the remapped color
+// indexes having to fit in the palette of the image, an error is
+// returned; the image will then have been changed anyway.
+func DecodeRemapped(
+	src []byte, dst *image.Paletted, c Codec, r Remap,
+) error {
+	rc := newRemapCodec(c, r)
+	Decode(src, dst, rc)
+
+	var decoded []uint8
+	for i, used := range rc.decoded {
+		if used {
+			decoded = append(decoded, uint8(i))
+		}
+	}
+	return r.Check(decoded, len(dst.Palette))
+}
+
+// usedColors returns the color indexes that are used in the image, in
+// ascending order.
+func usedColors(src image.PalettedImage) []uint8 {
+	var used [256]bool
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			used[src.ColorIndexAt(x, y)] = true
+		}
+	}
+	var colors []uint8
+	for c, u := range used {
+		if u {
+			colors = append(colors, uint8(c))
+		}
+	}
+	return colors
+}
+
+// remapCodec is a Codec that wraps another codec, remapping the color
+// indexes when encoding and decoding. Color indexes that are not in the
+// table are left as they are, but are recorded when decoding, to let
+// DecodeRemapped check them afterwards.
+type remapCodec struct {
+	Codec
+	table [256]uint8
+
+	// decoded records which color indexes have been decoded.
+	decoded *[256]bool
+}
+
+func newRemapCodec(c Codec, r Remap) remapCodec {
+	rc := remapCodec{Codec: c, decoded: new([256]bool)}
+	for i := range rc.table {
+		rc.table[i] = uint8(i)
+	}
+	for from, to := range r {
+		rc.table[from] = to
+	}
+	return rc
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c remapCodec) TileSize() (w, h int) {
+	return TileSize(c.Codec)
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c remapCodec) Encode(src SourceImage, x, y int, dst []byte) {
+	c.Codec.Encode(remapSource{src, &c.table}, x, y, dst)
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c remapCodec) Decode(src []byte, dst DestImage, x, y int) {
+	c.Codec.Decode(src, remapDest{dst, c}, x, y)
+}
+
+type remapSource struct {
+	src   SourceImage
+	table *[256]uint8
+}
+
+func (s remapSource) ColorIndexAt(x, y int) uint8 {
+	return s.table[s.src.ColorIndexAt(x, y)]
+}
+
+type remapDest struct {
+	dst DestImage
+	c   remapCodec
+}
+
+func (d remapDest) SetColorIndex(x, y int, idx uint8) {
+	d.c.decoded[idx] = true
+	d.dst.SetColorIndex(x, y, d.c.table[idx])
+}
@@ -0,0 +1,195 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// mapPixels returns a copy of the image with each color index changed
+// by the function, and with the given number of palette entries.
+func mapPixels(
+	src *image.Paletted, colors int, fn func(uint8) uint8,
+) *image.Paletted {
+	dst := image.NewPaletted(src.Rect, make(color.Palette, colors))
+	for i, p := range src.Pix {
+		dst.Pix[i] = fn(p)
+	}
+	return dst
+}
+
+func encodeAll(t *testing.T, src image.PalettedImage, c tileconv.Codec) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := tileconv.Encode(src, &buf, c); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeRemapped(t *testing.T) {
+	src := mapPixels(newRandomSheet(16, 8, 1), 4, func(c uint8) uint8 {
+		return c & 3
+	})
+	c := tileconv.RowPlanar{BitDepth: tileconv.BD4}
+
+	// Offset: moving a 2bpp tileset to the last 4 colors of a 4bpp one.
+	var buf bytes.Buffer
+	err := tileconv.EncodeRemapped(
+		src, &buf, c, tileconv.OffsetRemap(12), tileconv.BD4,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := encodeAll(t, mapPixels(src, 16, func(c uint8) uint8 {
+		return c + 12
+	}), c)
+	verify(t, "offset: bad encoded data", buf.Bytes(), want)
+
+	// Lookup table: swapping colors 0 and 3, on top of the identity.
+	r := tileconv.OffsetRemap(0)
+	r[0], r[3] = 3, 0
+	buf.Reset()
+	if err := tileconv.EncodeRemapped(src, &buf, c, r, c.BitDepth); err != nil {
+		t.Fatal(err)
+	}
+	want = encodeAll(t, mapPixels(src, 16, func(c uint8) uint8 {
+		return r[c]
+	}), c)
+	verify(t, "table: bad encoded data", buf.Bytes(), want)
+}
+
+func TestCompactRemap(t *testing.T) {
+	used := []uint8{200, 3, 9}
+	src := mapPixels(newRandomSheet(8, 8, 2), 256, func(c uint8) uint8 {
+		return used[int(c)%len(used)]
+	})
+
+	r := tileconv.CompactRemap(src)
+	want := tileconv.Remap{3: 0, 9: 1, 200: 2}
+	if len(r) != len(want) {
+		t.Fatalf("bad remap: want %v, got %v", want, r)
+	}
+	for from, to := range want {
+		if got, ok := r[from]; !ok || got != to {
+			t.Fatalf("bad remap: want %v, got %v", want, r)
+		}
+	}
+
+	c := tileconv.Packed{BitDepth: tileconv.BD2}
+	var buf bytes.Buffer
+	if err := tileconv.EncodeRemapped(src, &buf, c, r, c.BitDepth); err != nil {
+		t.Fatal(err)
+	}
+	expected := encodeAll(t, mapPixels(src, 4, func(c uint8) uint8 {
+		return want[c]
+	}), c)
+	verify(t, "bad encoded data", buf.Bytes(), expected)
+
+	// Three colors do not fit in 1bpp.
+	buf.Reset()
+	err := tileconv.EncodeRemapped(src, &buf, c, r, tileconv.BD1)
+	if err == nil {
+		t.Error("no error when compacting 3 colors into 1bpp")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("data was written despite error: %v bytes", buf.Len())
+	}
+}
+
+func TestEncodeRemapped_Errors(t *testing.T) {
+	src := mapPixels(newRandomSheet(8, 8, 3), 4, func(c uint8) uint8 {
+		return c & 3
+	})
+	c := tileconv.Packed{BitDepth: tileconv.BD8}
+	tests := map[string]tileconv.Remap{
+		"unmapped":  {0: 0, 1: 1, 2: 2},
+		"collision": {0: 0, 1: 1, 2: 2, 3: 1},
+		"too big":   tileconv.OffsetRemap(2),
+	}
+	for name, r := range tests {
+		var buf bytes.Buffer
+		err := tileconv.EncodeRemapped(src, &buf, c, r, tileconv.BD2)
+		if err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestDecodeRemapped(t *testing.T) {
+	src := mapPixels(newRandomSheet(16, 8, 4), 4, func(c uint8) uint8 {
+		return c & 3
+	})
+	c := tileconv.TilePlanar{BitDepth: tileconv.BD2}
+	data := encodeAll(t, src, c)
+
+	dst := image.NewPaletted(src.Rect, make(color.Palette, 16))
+	err := tileconv.DecodeRemapped(data, dst, c, tileconv.OffsetRemap(12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mapPixels(src, 16, func(c uint8) uint8 { return c + 12 })
+	verify(t, "bad decoded pixels", dst.Pix, want.Pix)
+
+	// The palette is too small for the remapped colors.
+	dst = image.NewPaletted(src.Rect, make(color.Palette, 8))
+	err = tileconv.DecodeRemapped(data, dst, c, tileconv.OffsetRemap(12))
+	if err == nil {
+		t.Error("no error when remapping beyond the palette")
+	}
+
+	// A decoded color is not in the table.
+	dst = image.NewPaletted(src.Rect, make(color.Palette, 16))
+	err = tileconv.DecodeRemapped(data, dst, c, tileconv.Remap{0: 1, 1: 0})
+	if err == nil {
+		t.Error("no error when decoding an unmapped color")
+	}
+}
+
+func TestRemapInverse(t *testing.T) {
+	r := tileconv.Remap{0: 15, 1: 4, 15: 0}
+	inv, err := r.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for from, to := range r {
+		if got, ok := inv[to]; !ok || got != from {
+			t.Errorf("bad inverse of %v: want %v, got %v", to, from, got)
+		}
+	}
+	if len(inv) != len(r) {
+		t.Errorf("bad inverse size: want %v, got %v", len(r), len(inv))
+	}
+
+	if _, err := (tileconv.Remap{0: 1, 1: 1}).Inverse(); err == nil {
+		t.Error("no error when inverting a lossy remap")
+	}
+}
+
+func TestEncodeRemapped_Padding(t *testing.T) {
+	// The image uses colors 1-3, but Encode also uses color 0 for the
+	// padding when the image is not a whole number of tiles.
+	src := mapPixels(newRandomSheet(16, 8, 5), 4, func(c uint8) uint8 {
+		return c%3 + 1
+	})
+	padded := src.SubImage(image.Rect(0, 0, 12, 8)).(*image.Paletted)
+	c := tileconv.Packed{BitDepth: tileconv.BD2}
+	tests := map[string]tileconv.Remap{
+		"unmapped":  {1: 0, 2: 1, 3: 2},
+		"collision": {0: 1, 1: 1, 2: 2, 3: 3},
+	}
+	for name, r := range tests {
+		var buf bytes.Buffer
+		err := tileconv.EncodeRemapped(src, &buf, c, r, c.BitDepth)
+		if err != nil {
+			t.Errorf("%s: unpadded: %v", name, err)
+		}
+		err = tileconv.EncodeRemapped(padded, &buf, c, r, c.BitDepth)
+		if err == nil {
+			t.Errorf("%s: padded: no error", name)
+		}
+	}
+}
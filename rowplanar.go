@@ -1,5 +1,9 @@
 package tileconv
 
+import (
+	"image"
+)
+
 // RowPlanar is a Codec that encodes each tile as a planar image, with
 // the planes for each row stored contiguously. In other words, all the
 // data for the first row is stored before the data for the second, and
@@ -9,6 +13,7 @@ type RowPlanar struct {
 }
 
 var _ Codec = RowPlanar{}
+var _ BulkCodec = RowPlanar{}
 
 // Size implements Codec, returning the size of a tile.
 func (c RowPlanar) Size() int {
@@ -19,13 +24,9 @@ func (c RowPlanar) Size() int {
 func (c RowPlanar) Encode(src SourceImage, x, y int, dst []byte) {
 	planes := c.BitDepth.Planes()
 	for iy := 0; iy < 8; iy++ {
-		for ix := 0; ix < 8; ix++ {
-			color := src.ColorIndexAt(x+ix, y+iy)
-			for p := 0; p < planes; p++ {
-				i := iy*planes + p
-				dst[i] = (dst[i] << 1) | (color & 1)
-				color >>= 1
-			}
+		row := readRow(src, x, y+iy)
+		for p := 0; p < planes; p++ {
+			dst[iy*planes+p] = planeByte(row, p)
 		}
 	}
 }
@@ -34,16 +35,35 @@ func (c RowPlanar) Encode(src SourceImage, x, y int, dst []byte) {
 func (c RowPlanar) Decode(src []byte, dst DestImage, x, y int) {
 	planes := c.BitDepth.Planes()
 	for iy := 0; iy < 8; iy++ {
-		row := [8]uint8{}
+		row := uint64(0)
 		for p := 0; p < planes; p++ {
-			d := src[iy*planes+p]
-			for ix := 8 - 1; ix >= 0; ix-- {
-				row[ix] |= (d & 1) << p
-				d >>= 1
-			}
+			row |= spreadBits[src[iy*planes+p]] << p
 		}
-		for ix := 0; ix < 8; ix++ {
-			dst.SetColorIndex(x+ix, y+iy, row[ix])
+		writeRow(dst, x, y+iy, row)
+	}
+}
+
+// tiles returns the layout of the tiles, for the BulkCodec methods.
+func (c RowPlanar) tiles() *planarTiles {
+	l := &planarTiles{
+		size:   c.Size(),
+		planes: c.BitDepth.Planes(),
+		mask:   uint64(c.BitDepth.ColorMask()) * lowBits,
+	}
+	for iy := 0; iy < 8; iy++ {
+		for p := 0; p < l.planes; p++ {
+			l.offset[iy][p] = iy*l.planes + p
 		}
 	}
+	return l
+}
+
+// EncodeTiles implements BulkCodec, encoding a run of tiles into bytes.
+func (c RowPlanar) EncodeTiles(src *image.Paletted, x, y, n int, dst []byte) {
+	c.tiles().encode(src, x, y, n, dst)
+}
+
+// DecodeTiles implements BulkCodec, decoding bytes into a run of tiles.
+func (c RowPlanar) DecodeTiles(src []byte, dst *image.Paletted, x, y, n int) {
+	c.tiles().decode(src, dst, x, y, n)
 }
@@ -0,0 +1,197 @@
+package tileconv
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	// SpectrumWidth and SpectrumHeight are the size of a ZX Spectrum
+	// screen, in pixels.
+	SpectrumWidth  = 256
+	SpectrumHeight = 192
+
+	// SpectrumScreenSize is the size of a ZX Spectrum screen (.scr)
+	// file: the 1bpp bitmap followed by the 8x8 cell attributes.
+	SpectrumScreenSize = spectrumBitmapSize + spectrumAttrSize
+
+	spectrumBitmapSize = SpectrumWidth * SpectrumHeight / 8
+	spectrumAttrSize   = SpectrumWidth / 8 * SpectrumHeight / 8
+
+	spectrumBright = 0x40
+)
+
+// SpectrumPalette is the palette of the ZX Spectrum, with the normal
+// colors at indexes 0-7 and their bright versions at indexes 8-15.
+//
+// Since bright black is the same as black, it only has 15 colors.
+var SpectrumPalette = color.Palette{
+	color.RGBA{0x00, 0x00, 0x00, 0xFF}, // black
+	color.RGBA{0x00, 0x00, 0xD7, 0xFF}, // blue
+	color.RGBA{0xD7, 0x00, 0x00, 0xFF}, // red
+	color.RGBA{0xD7, 0x00, 0xD7, 0xFF}, // magenta
+	color.RGBA{0x00, 0xD7, 0x00, 0xFF}, // green
+	color.RGBA{0x00, 0xD7, 0xD7, 0xFF}, // cyan
+	color.RGBA{0xD7, 0xD7, 0x00, 0xFF}, // yellow
+	color.RGBA{0xD7, 0xD7, 0xD7, 0xFF}, // white
+	color.RGBA{0x00, 0x00, 0x00, 0xFF}, // bright black
+	color.RGBA{0x00, 0x00, 0xFF, 0xFF}, // bright blue
+	color.RGBA{0xFF, 0x00, 0x00, 0xFF}, // bright red
+	color.RGBA{0xFF, 0x00, 0xFF, 0xFF}, // bright magenta
+	color.RGBA{0x00, 0xFF, 0x00, 0xFF}, // bright green
+	color.RGBA{0x00, 0xFF, 0xFF, 0xFF}, // bright cyan
+	color.RGBA{0xFF, 0xFF, 0x00, 0xFF}, // bright yellow
+	color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, // bright white
+}
+
+// spectrumRowOffset returns the offset of the given pixel row in the
+// bitmap, which is split into thirds that each store the first row of
+// all their cell rows, then the second row of them all, and so on.
+func spectrumRowOffset(y int) int {
+	return (y&0xC0)<<5 | (y&0x07)<<8 | (y&0x38)<<2
+}
+
+// EncodeSpectrumScreen encodes an image into a ZX Spectrum screen
+// (.scr) file.
+//
+// The image is read starting at its top-left corner, and must use the
+// SpectrumPalette color indexes (0-15). Each 8x8 cell can use at most
+// two colors, which must either be all normal or all bright (black can
+// be used as either). If any cells do not follow that, a
+// *ColorClashError is returned that describes all of those cells.
+//
+// The lower of the two colors becomes the paper, the other the ink, and
+// cells with a single color have all their pixels set to paper. The
+// flash bit is never set.
+func EncodeSpectrumScreen(src image.PalettedImage) ([]byte, error) {
+	data := make([]byte, SpectrumScreenSize)
+	bitmap, attrs := data[:spectrumBitmapSize], data[spectrumBitmapSize:]
+
+	var clashes []ColorClash
+	min := src.Bounds().Min
+	for cy := 0; cy < SpectrumHeight/8; cy++ {
+		for cx := 0; cx < SpectrumWidth/8; cx++ {
+			cell := image.Rect(0, 0, 8, 8).Add(
+				min.Add(image.Pt(cx*8, cy*8)),
+			)
+			colors := cellColors(src, cell, 1)
+
+			attr, clash, err := spectrumAttr(colors)
+			if err != nil {
+				return nil, fmt.Errorf("cell at %v: %w", cell.Min, err)
+			}
+			if clash != nil {
+				clash.Cell = cell
+				clashes = append(clashes, *clash)
+				continue
+			}
+			attrs[cy*SpectrumWidth/8+cx] = attr
+
+			paper := attr >> 3 & 7
+			for py := 0; py < 8; py++ {
+				var b byte
+				for px := 0; px < 8; px++ {
+					c := src.ColorIndexAt(cell.Min.X+px, cell.Min.Y+py)
+					b <<= 1
+					if c&7 != paper {
+						b |= 1
+					}
+				}
+				bitmap[spectrumRowOffset(cy*8+py)+cx] = b
+			}
+		}
+	}
+
+	if len(clashes) > 0 {
+		return nil, &ColorClashError{Clashes: clashes}
+	}
+
+	return data, nil
+}
+
+// spectrumAttr returns the attribute byte for a cell that uses the
+// given colors, or a description of why they clash.
+func spectrumAttr(colors []uint8) (byte, *ColorClash, error) {
+	var normal, bright bool
+	var used [8]bool
+	for _, c := range colors {
+		if c > 15 {
+			return 0, nil, fmt.Errorf("invalid Spectrum color %v", c)
+		}
+		// Black works with both normal and bright.
+		if c&7 != 0 {
+			normal = normal || c < 8
+			bright = bright || c >= 8
+		}
+		used[c&7] = true
+	}
+
+	var inkPaper []uint8
+	for c, u := range used {
+		if u {
+			inkPaper = append(inkPaper, uint8(c))
+		}
+	}
+
+	switch {
+	case normal && bright:
+		return 0, &ColorClash{
+			Colors: colors, Max: 2,
+			Reason: "mixes normal and bright colors",
+		}, nil
+	case len(inkPaper) > 2:
+		return 0, &ColorClash{Colors: colors, Max: 2}, nil
+	}
+
+	// The colors are in ascending order, so the paper is the lower one.
+	// If there is only one color, it is used for both.
+	paper, ink := inkPaper[0], inkPaper[len(inkPaper)-1]
+	attr := paper<<3 | ink
+	if bright {
+		attr |= spectrumBright
+	}
+	return attr, nil, nil
+}
+
+// DecodeSpectrumScreen decodes a ZX Spectrum screen (.scr) file into a
+// new image that uses the SpectrumPalette. The flash bit is ignored,
+// and black is always decoded as index 0, even in bright cells.
+func DecodeSpectrumScreen(data []byte) (*image.Paletted, error) {
+	if len(data) != SpectrumScreenSize {
+		return nil, errors.New("invalid Spectrum screen size")
+	}
+	bitmap, attrs := data[:spectrumBitmapSize], data[spectrumBitmapSize:]
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, SpectrumWidth, SpectrumHeight),
+		append(SpectrumPalette[:0:0], SpectrumPalette...),
+	)
+	for y := 0; y < SpectrumHeight; y++ {
+		row := bitmap[spectrumRowOffset(y):]
+		for cx := 0; cx < SpectrumWidth/8; cx++ {
+			attr := attrs[y/8*SpectrumWidth/8+cx]
+			ink, paper := attr&7, attr>>3&7
+			if attr&spectrumBright != 0 {
+				if ink != 0 {
+					ink += 8
+				}
+				if paper != 0 {
+					paper += 8
+				}
+			}
+			b := row[cx]
+			for px := 0; px < 8; px++ {
+				c := paper
+				if b&0x80 != 0 {
+					c = ink
+				}
+				b <<= 1
+				img.SetColorIndex(cx*8+px, y, c)
+			}
+		}
+	}
+
+	return img, nil
+}
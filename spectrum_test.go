@@ -0,0 +1,114 @@
+package tileconv_test
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func newSpectrumTestImage() *image.Paletted {
+	return image.NewPaletted(
+		image.Rect(0, 0, 256, 192), tileconv.SpectrumPalette,
+	)
+}
+
+func TestEncodeSpectrumScreen_Layout(t *testing.T) {
+	src := newSpectrumTestImage()
+	// Cell 0,0 is blue on black; the other cells are black.
+	src.SetColorIndex(0, 1, 1)
+	// Cell 1,1 is bright red on bright black.
+	src.SetColorIndex(9, 8, 10)
+	src.SetColorIndex(10, 9, 8)
+	// Cell 0,8 (in the second third) is white on yellow, since the
+	// lower color is used as the paper.
+	for y := 64; y < 72; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetColorIndex(x, y, 7)
+		}
+	}
+	src.SetColorIndex(7, 64, 6)
+
+	data, err := tileconv.EncodeSpectrumScreen(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verify(t, "bad size", len(data), tileconv.SpectrumScreenSize)
+
+	want := make([]byte, tileconv.SpectrumScreenSize)
+	want[0x100] = 0b10000000
+	want[0x020+1] = 0b01000000
+	want[0x800] = 0b11111110
+	for i := 1; i < 8; i++ {
+		want[0x800+i*0x100] = 0b11111111
+	}
+	want[0x1800] = 0<<3 | 1
+	want[0x1800+32+1] = 0x40 | 0<<3 | 2
+	want[0x1800+8*32] = 6<<3 | 7
+	verify(t, "bad screen data", data, want)
+}
+
+func TestSpectrumScreenRoundTrip(t *testing.T) {
+	src := newSpectrumTestImage()
+	for y := 0; y < 192; y++ {
+		for x := 0; x < 256; x++ {
+			cell := x/8 + y/8*32
+			c := uint8(cell % 8)
+			if (x^y*3)%5 < 2 {
+				c = uint8((cell/8 + 3) % 8)
+			}
+			if cell%3 == 0 && c != 0 {
+				c += 8
+			}
+			src.SetColorIndex(x, y, c)
+		}
+	}
+
+	data, err := tileconv.EncodeSpectrumScreen(src)
+	if err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	got, err := tileconv.DecodeSpectrumScreen(data)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	verify(t, "bad decoded image", got, src)
+
+	if _, err := tileconv.DecodeSpectrumScreen(data[1:]); err == nil {
+		t.Errorf("missing error for invalid size")
+	}
+}
+
+func TestEncodeSpectrumScreen_Clash(t *testing.T) {
+	src := newSpectrumTestImage()
+	// Cell 0,0 has three colors.
+	src.SetColorIndex(0, 0, 1)
+	src.SetColorIndex(1, 0, 2)
+	// Cell 2,0 mixes normal and bright.
+	src.SetColorIndex(16, 0, 3)
+	src.SetColorIndex(17, 0, 11)
+	// Cell 3,0 has bright black with normal, which is OK.
+	src.SetColorIndex(24, 0, 8)
+	src.SetColorIndex(25, 0, 4)
+
+	_, err := tileconv.EncodeSpectrumScreen(src)
+
+	var ce *tileconv.ColorClashError
+	if !errors.As(err, &ce) {
+		t.Fatalf("wrong error: want *ColorClashError, got %#v", err)
+	}
+	verify(t, "bad clashes", ce.Clashes, []tileconv.ColorClash{
+		{Cell: image.Rect(0, 0, 8, 8), Colors: []uint8{0, 1, 2}, Max: 2},
+		{
+			Cell: image.Rect(16, 0, 24, 8), Colors: []uint8{0, 3, 11},
+			Max: 2, Reason: "mixes normal and bright colors",
+		},
+	})
+
+	src.SetColorIndex(0, 0, 16)
+	if _, err := tileconv.EncodeSpectrumScreen(src); err == nil {
+		t.Errorf("missing error for invalid color")
+	}
+}
@@ -0,0 +1,180 @@
+package tileconv
+
+import (
+	"image"
+	"io"
+)
+
+// Sprite is a single object in a sprite sheet, as found by FindSprites
+// or GridSprites.
+type Sprite struct {
+	// Bounds is the area of the sheet that is encoded for the sprite. It
+	// starts at the top-left corner of Trim, and is a whole number of
+	// tiles in size.
+	Bounds image.Rectangle
+
+	// Trim is the smallest area that holds all the pixels of the sprite.
+	// Pixels in Bounds that are outside of it are encoded as color 0.
+	Trim image.Rectangle
+}
+
+// Tiles returns the size of the sprite, in tiles of the given size.
+func (s Sprite) Tiles(tw, th int) (cols, rows int) {
+	return s.Bounds.Dx() / tw, s.Bounds.Dy() / th
+}
+
+// newSprite returns the sprite for the given trimmed area, with Bounds
+// grown to the right and down to a whole number of tiles.
+func newSprite(trim image.Rectangle, tw, th int) Sprite {
+	w := (trim.Dx() + tw - 1) / tw * tw
+	h := (trim.Dy() + th - 1) / th * th
+	return Sprite{
+		Bounds: image.Rectangle{trim.Min, trim.Min.Add(image.Pt(w, h))},
+		Trim:   trim,
+	}
+}
+
+// FindSprites finds the separate objects in a sprite sheet that has a
+// transparent background of color 0, and returns them with Bounds being
+// a whole number of tiles of the given size. They are in the order that
+// their first pixels are found when scanning the image row by row, top
+// to bottom and left to right; this is not always the order of the
+// top-left corners of their bounds.
+//
+// An object is a group of pixels that are not color 0, where each pixel
+// touches another pixel of the group, including diagonally. If the
+// bounding boxes of two objects overlap, they are still separate, but
+// each sprite will include the pixels of the other that are within it.
+func FindSprites(src image.PalettedImage, tw, th int) []Sprite {
+	b := src.Bounds()
+	w := b.Dx()
+	seen := make([]bool, w*b.Dy())
+	var sprites []Sprite
+	var stack []image.Point
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i := (y-b.Min.Y)*w + x - b.Min.X
+			if seen[i] || src.ColorIndexAt(x, y) == 0 {
+				continue
+			}
+
+			// Flood fill the object, to find its bounding box.
+			seen[i] = true
+			trim := image.Rect(x, y, x+1, y+1)
+			stack = append(stack[:0], image.Pt(x, y))
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				trim = trim.Union(image.Rect(p.X, p.Y, p.X+1, p.Y+1))
+				for ny := p.Y - 1; ny <= p.Y+1; ny++ {
+					for nx := p.X - 1; nx <= p.X+1; nx++ {
+						if !(image.Point{nx, ny}).In(b) {
+							continue
+						}
+						n := (ny-b.Min.Y)*w + nx - b.Min.X
+						if seen[n] || src.ColorIndexAt(nx, ny) == 0 {
+							continue
+						}
+						seen[n] = true
+						stack = append(stack, image.Pt(nx, ny))
+					}
+				}
+			}
+
+			sprites = append(sprites, newSprite(trim, tw, th))
+		}
+	}
+
+	return sprites
+}
+
+// GridSprites splits a sprite sheet into cells of the given size, from
+// the top-left corner, and returns a sprite for each cell that has any
+// pixels that are not color 0, in row-major order. Each sprite is
+// trimmed to those pixels, with Bounds being a whole number of tiles of
+// the given size.
+//
+// Pixels from other cells are never included in a sprite, even if its
+// Bounds extend into them.
+//
+// If the cell width or height is not positive, it returns nil.
+func GridSprites(src image.PalettedImage, cw, ch, tw, th int) []Sprite {
+	if cw <= 0 || ch <= 0 {
+		return nil
+	}
+	b := src.Bounds()
+	var sprites []Sprite
+	for cy := b.Min.Y; cy < b.Max.Y; cy += ch {
+		for cx := b.Min.X; cx < b.Max.X; cx += cw {
+			cell := image.Rect(cx, cy, cx+cw, cy+ch).Intersect(b)
+			var trim image.Rectangle
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					if src.ColorIndexAt(x, y) != 0 {
+						trim = trim.Union(image.Rect(x, y, x+1, y+1))
+					}
+				}
+			}
+			if !trim.Empty() {
+				sprites = append(sprites, newSprite(trim, tw, th))
+			}
+		}
+	}
+	return sprites
+}
+
+// SpriteData describes where the encoded data of a sprite was written by
+// EncodeSprites.
+type SpriteData struct {
+	Sprite
+
+	// Offset is the position of the sprite's data, in bytes from the
+	// start of the data written by EncodeSprites.
+	Offset int
+
+	// Size is the length of the sprite's data, in bytes.
+	Size int
+}
+
+// EncodeSprites encodes each of the sprites separately, as if by using
+// Encode on an image holding only that sprite, and writes them one after
+// the other into the given writer. It returns where the data of each
+// sprite was written.
+func EncodeSprites(
+	src image.PalettedImage, sprites []Sprite, dst io.Writer, c Codec,
+) ([]SpriteData, error) {
+	data := make([]SpriteData, 0, len(sprites))
+	offset := 0
+	for _, s := range sprites {
+		t := newTileRows(s.Bounds, c)
+		d := SpriteData{
+			Sprite: s, Offset: offset, Size: t.rows * t.rowSize(),
+		}
+		if err := Encode(spriteImage{src, s}, dst, c); err != nil {
+			return data, err
+		}
+		data = append(data, d)
+		offset += d.Size
+	}
+	return data, nil
+}
+
+// spriteImage is the image of a single sprite, as given to Encode by
+// EncodeSprites. Only its ColorIndexAt is masked to the sprite, since
+// that is all that Encode uses.
+type spriteImage struct {
+	image.PalettedImage
+	s Sprite
+}
+
+func (i spriteImage) Bounds() image.Rectangle {
+	return i.s.Bounds
+}
+
+func (i spriteImage) ColorIndexAt(x, y int) uint8 {
+	if !(image.Point{x, y}).In(i.s.Trim) {
+		return 0
+	}
+	return i.PalettedImage.ColorIndexAt(x, y)
+}
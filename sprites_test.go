@@ -0,0 +1,104 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// newSpriteSheet returns a 32x24 image with three objects on a color 0
+// background: a diagonal line at 1,1-3,3, a 10x3 bar at 20,2 and a
+// single pixel at 5,20.
+func newSpriteSheet() *image.Paletted {
+	img := image.NewPaletted(
+		image.Rect(0, 0, 32, 24), make(color.Palette, 16),
+	)
+	for i := 1; i <= 3; i++ {
+		img.SetColorIndex(i, i, uint8(i))
+	}
+	for y := 2; y < 5; y++ {
+		for x := 20; x < 30; x++ {
+			img.SetColorIndex(x, y, 7)
+		}
+	}
+	img.SetColorIndex(5, 20, 15)
+	return img
+}
+
+func TestFindSprites(t *testing.T) {
+	got := tileconv.FindSprites(newSpriteSheet(), 8, 8)
+	want := []tileconv.Sprite{
+		{Bounds: image.Rect(1, 1, 9, 9), Trim: image.Rect(1, 1, 4, 4)},
+		{Bounds: image.Rect(20, 2, 36, 10), Trim: image.Rect(20, 2, 30, 5)},
+		{Bounds: image.Rect(5, 20, 13, 28), Trim: image.Rect(5, 20, 6, 21)},
+	}
+	verify(t, "bad sprites", got, want)
+}
+
+func TestGridSprites(t *testing.T) {
+	// With 16x16 cells, each object is in a cell of its own, and the
+	// empty cells are skipped.
+	got := tileconv.GridSprites(newSpriteSheet(), 16, 16, 8, 8)
+	want := []tileconv.Sprite{
+		{Bounds: image.Rect(1, 1, 9, 9), Trim: image.Rect(1, 1, 4, 4)},
+		{Bounds: image.Rect(20, 2, 36, 10), Trim: image.Rect(20, 2, 30, 5)},
+		{Bounds: image.Rect(5, 20, 13, 28), Trim: image.Rect(5, 20, 6, 21)},
+	}
+	verify(t, "bad sprites", got, want)
+
+	// With 8x8 cells, the bar is split in two at x=24.
+	got = tileconv.GridSprites(newSpriteSheet(), 8, 8, 8, 8)
+	want = []tileconv.Sprite{
+		{Bounds: image.Rect(1, 1, 9, 9), Trim: image.Rect(1, 1, 4, 4)},
+		{Bounds: image.Rect(20, 2, 28, 10), Trim: image.Rect(20, 2, 24, 5)},
+		{Bounds: image.Rect(24, 2, 32, 10), Trim: image.Rect(24, 2, 30, 5)},
+		{Bounds: image.Rect(5, 20, 13, 28), Trim: image.Rect(5, 20, 6, 21)},
+	}
+	verify(t, "bad split sprites", got, want)
+
+	for _, size := range [][2]int{{0, 8}, {8, 0}, {-8, 8}} {
+		got = tileconv.GridSprites(newSpriteSheet(), size[0], size[1], 8, 8)
+		if got != nil {
+			t.Errorf("%vx%v cells: want nil, got %v", size[0], size[1], got)
+		}
+	}
+}
+
+func TestEncodeSprites(t *testing.T) {
+	src := newSpriteSheet()
+	// A stray pixel inside the bounds of the first sprite, but outside
+	// of its trimmed area, which must not be encoded with it.
+	src.SetColorIndex(6, 6, 9)
+	sprites := []tileconv.Sprite{
+		{Bounds: image.Rect(1, 1, 9, 9), Trim: image.Rect(1, 1, 4, 4)},
+		{Bounds: image.Rect(20, 2, 36, 10), Trim: image.Rect(20, 2, 30, 5)},
+	}
+	c := tileconv.Packed{BitDepth: tileconv.BD4}
+
+	var buf bytes.Buffer
+	got, err := tileconv.EncodeSprites(src, sprites, &buf, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tileconv.SpriteData{
+		{Sprite: sprites[0], Offset: 0, Size: 32},
+		{Sprite: sprites[1], Offset: 32, Size: 64},
+	}
+	verify(t, "bad sprite data", got, want)
+
+	first := image.NewPaletted(image.Rect(0, 0, 8, 8), src.Palette)
+	for i := 0; i < 3; i++ {
+		first.SetColorIndex(i, i, uint8(i+1))
+	}
+	second := image.NewPaletted(image.Rect(0, 0, 16, 8), src.Palette)
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 10; x++ {
+			second.SetColorIndex(x, y, 7)
+		}
+	}
+	expected := append(encodeAll(t, first, c), encodeAll(t, second, c)...)
+	verify(t, "bad encoded data", buf.Bytes(), expected)
+}
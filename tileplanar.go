@@ -1,5 +1,9 @@
 package tileconv
 
+import (
+	"image"
+)
+
 // TilePlanar is a Codec that encodes each tile as a planar image, with
 // each plane of the tile stored contiguously - such that a tile with
 // bit depth N can be extended to N+1 bits by appending a zeroed plane.
@@ -8,6 +12,7 @@ type TilePlanar struct {
 }
 
 var _ Codec = TilePlanar{}
+var _ BulkCodec = TilePlanar{}
 
 // Size implements Codec, returning the size of a tile.
 func (c TilePlanar) Size() int {
@@ -18,13 +23,9 @@ func (c TilePlanar) Size() int {
 func (c TilePlanar) Encode(src SourceImage, x, y int, dst []byte) {
 	planes := c.BitDepth.Planes()
 	for iy := 0; iy < 8; iy++ {
-		for ix := 0; ix < 8; ix++ {
-			color := src.ColorIndexAt(x+ix, y+iy)
-			for p := 0; p < planes; p++ {
-				i := iy + p*BytesPerPlane
-				dst[i] = (dst[i] << 1) | (color & 1)
-				color >>= 1
-			}
+		row := readRow(src, x, y+iy)
+		for p := 0; p < planes; p++ {
+			dst[iy+p*BytesPerPlane] = planeByte(row, p)
 		}
 	}
 }
@@ -33,16 +34,35 @@ func (c TilePlanar) Encode(src SourceImage, x, y int, dst []byte) {
 func (c TilePlanar) Decode(src []byte, dst DestImage, x, y int) {
 	planes := c.BitDepth.Planes()
 	for iy := 0; iy < 8; iy++ {
-		row := [8]uint8{}
+		row := uint64(0)
 		for p := 0; p < planes; p++ {
-			d := src[iy+p*BytesPerPlane]
-			for ix := 8 - 1; ix >= 0; ix-- {
-				row[ix] |= (d & 1) << p
-				d >>= 1
-			}
+			row |= spreadBits[src[iy+p*BytesPerPlane]] << p
 		}
-		for ix := 0; ix < 8; ix++ {
-			dst.SetColorIndex(x+ix, y+iy, row[ix])
+		writeRow(dst, x, y+iy, row)
+	}
+}
+
+// tiles returns the layout of the tiles, for the BulkCodec methods.
+func (c TilePlanar) tiles() *planarTiles {
+	l := &planarTiles{
+		size:   c.Size(),
+		planes: c.BitDepth.Planes(),
+		mask:   uint64(c.BitDepth.ColorMask()) * lowBits,
+	}
+	for iy := 0; iy < 8; iy++ {
+		for p := 0; p < l.planes; p++ {
+			l.offset[iy][p] = iy + p*BytesPerPlane
 		}
 	}
+	return l
+}
+
+// EncodeTiles implements BulkCodec, encoding a run of tiles into bytes.
+func (c TilePlanar) EncodeTiles(src *image.Paletted, x, y, n int, dst []byte) {
+	c.tiles().encode(src, x, y, n, dst)
+}
+
+// DecodeTiles implements BulkCodec, decoding bytes into a run of tiles.
+func (c TilePlanar) DecodeTiles(src []byte, dst *image.Paletted, x, y, n int) {
+	c.tiles().decode(src, dst, x, y, n)
 }
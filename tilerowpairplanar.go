@@ -1,5 +1,9 @@
 package tileconv
 
+import (
+	"image"
+)
+
 // TileRowPairPlanar is a Codec that encodes each tile as a planar
 // image, with the planes stored in pairs (as if it was a sequence of
 // 2bpp tiles), and each plane pair stored in a row-planar manner.
@@ -14,6 +18,7 @@ type TileRowPairPlanar struct {
 }
 
 var _ Codec = TileRowPairPlanar{}
+var _ BulkCodec = TileRowPairPlanar{}
 
 // Size implements Codec, returning the size of a tile.
 func (c TileRowPairPlanar) Size() int {
@@ -23,20 +28,12 @@ func (c TileRowPairPlanar) Size() int {
 // Encode implements Codec, encoding a tile image into bytes.
 func (c TileRowPairPlanar) Encode(s SourceImage, x, y int, d []byte) {
 	planes := c.BitDepth.Planes()
-	mask := c.BitDepth.ColorMask()
+	mask := uint64(c.BitDepth.ColorMask()) * lowBits
 	for iy := 0; iy < 8; iy++ {
-		for ix := 0; ix < 8; ix++ {
-			color := s.ColorIndexAt(x+ix, y+iy)
-			color &= mask
-			for p := 0; p < planes; p += 2 {
-				i := iy*2 + p*BytesPerPlane + 0
-				d[i] = (d[i] << 1) | (color & 1)
-				color >>= 1
-
-				i = iy*2 + p*BytesPerPlane + 1
-				d[i] = (d[i] << 1) | (color & 1)
-				color >>= 1
-			}
+		row := readRow(s, x, y+iy) & mask
+		for p := 0; p < planes; p += 2 {
+			d[iy*2+p*BytesPerPlane+0] = planeByte(row, p+0)
+			d[iy*2+p*BytesPerPlane+1] = planeByte(row, p+1)
 		}
 	}
 }
@@ -44,24 +41,42 @@ func (c TileRowPairPlanar) Encode(s SourceImage, x, y int, d []byte) {
 // Decode implements Codec, decoding bytes into an image.
 func (c TileRowPairPlanar) Decode(src []byte, dst DestImage, x, y int) {
 	planes := c.BitDepth.Planes()
-	mask := c.BitDepth.ColorMask()
+	mask := uint64(c.BitDepth.ColorMask()) * lowBits
 	for iy := 0; iy < 8; iy++ {
-		row := [8]uint8{}
+		row := uint64(0)
 		for p := 0; p < planes; p += 2 {
-			d := src[iy*2+p*BytesPerPlane+0]
-			for ix := 8 - 1; ix >= 0; ix-- {
-				row[ix] |= (d & 1) << (p + 0)
-				d >>= 1
-			}
-
-			d = src[iy*2+p*BytesPerPlane+1]
-			for ix := 8 - 1; ix >= 0; ix-- {
-				row[ix] |= (d & 1) << (p + 1)
-				d >>= 1
-			}
+			row |= spreadBits[src[iy*2+p*BytesPerPlane+0]] << (p + 0)
+			row |= spreadBits[src[iy*2+p*BytesPerPlane+1]] << (p + 1)
 		}
-		for ix := 0; ix < 8; ix++ {
-			dst.SetColorIndex(x+ix, y+iy, row[ix]&mask)
+		writeRow(dst, x, y+iy, row&mask)
+	}
+}
+
+// tiles returns the layout of the tiles, for the BulkCodec methods.
+func (c TileRowPairPlanar) tiles() *planarTiles {
+	l := &planarTiles{
+		size:   c.Size(),
+		planes: c.Size() / BytesPerPlane,
+		mask:   uint64(c.BitDepth.ColorMask()) * lowBits,
+	}
+	for iy := 0; iy < 8; iy++ {
+		for p := 0; p < l.planes; p++ {
+			l.offset[iy][p] = iy*2 + (p&^1)*BytesPerPlane + p&1
 		}
 	}
+	return l
+}
+
+// EncodeTiles implements BulkCodec, encoding a run of tiles into bytes.
+func (c TileRowPairPlanar) EncodeTiles(
+	src *image.Paletted, x, y, n int, dst []byte,
+) {
+	c.tiles().encode(src, x, y, n, dst)
+}
+
+// DecodeTiles implements BulkCodec, decoding bytes into a run of tiles.
+func (c TileRowPairPlanar) DecodeTiles(
+	src []byte, dst *image.Paletted, x, y, n int,
+) {
+	c.tiles().decode(src, dst, x, y, n)
 }
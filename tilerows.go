@@ -0,0 +1,113 @@
+package tileconv
+
+import (
+	"image"
+)
+
+// tileRows describes the rows of tiles that cover an image when using
+// a given codec, for encoding or decoding one row of tiles at a time.
+type tileRows struct {
+	bounds image.Rectangle
+	tw, th int
+	size   int
+
+	cols, rows int
+}
+
+func newTileRows(b image.Rectangle, c Codec) tileRows {
+	t := tileRows{bounds: b, size: c.Size()}
+	t.tw, t.th = TileSize(c)
+	if b.Dx() > 0 && b.Dy() > 0 {
+		t.cols = (b.Dx() + t.tw - 1) / t.tw
+		t.rows = (b.Dy() + t.th - 1) / t.th
+	}
+	return t
+}
+
+// rowSize returns the size of the encoded data for a row of tiles.
+func (t tileRows) rowSize() int {
+	return t.cols * t.size
+}
+
+// rowsIn returns the number of rows of tiles that have data for at least
+// one tile in encoded data of the given length.
+func (t tileRows) rowsIn(length int) int {
+	if t.rows == 0 || t.cols == 0 {
+		return 0
+	}
+	if t.size <= 0 {
+		return t.rows
+	}
+	if length < t.size {
+		return 0
+	}
+	if n := (length-t.size)/t.rowSize() + 1; n < t.rows {
+		return n
+	}
+	return t.rows
+}
+
+// full returns the number of tiles in the given row that are fully
+// inside of the image.
+func (t tileRows) full(row int) int {
+	if t.bounds.Min.Y+(row+1)*t.th > t.bounds.Max.Y {
+		return 0
+	}
+	return t.bounds.Dx() / t.tw
+}
+
+// encodeRow encodes the given row of tiles into buf, which must be at
+// least rowSize() bytes long. If possible, it uses the BulkCodec methods
+// for the tiles that are fully inside of the image.
+func (t tileRows) encodeRow(
+	src image.PalettedImage, c Codec, row int, buf []byte,
+) {
+	x, y := t.bounds.Min.X, t.bounds.Min.Y+row*t.th
+	first := 0
+	if bc, ok := c.(BulkCodec); ok {
+		if img, ok := src.(*image.Paletted); ok {
+			if first = t.full(row); first > 0 {
+				bc.EncodeTiles(img, x, y, first, buf)
+			}
+		}
+	}
+	for i := first; i < t.cols; i++ {
+		c.Encode(src, x+i*t.tw, y, buf[i*t.size:(i+1)*t.size])
+	}
+}
+
+// decodeRow decodes the given row of tiles from src, which holds the
+// encoded data of all the rows, and returns the number of tiles that
+// it decoded, which is less than a full row if src ends in that row. If
+// possible, it uses the BulkCodec methods for the tiles that are fully
+// inside of the image.
+func (t tileRows) decodeRow(
+	src []byte, dst *image.Paletted, c Codec, row int,
+) int {
+	n := t.cols
+	if t.size > 0 {
+		start := row * t.rowSize()
+		if start >= len(src) {
+			return 0
+		}
+		src = src[start:]
+		if m := len(src) / t.size; m < n {
+			n = m
+		}
+	}
+
+	x, y := t.bounds.Min.X, t.bounds.Min.Y+row*t.th
+	first := 0
+	if bc, ok := c.(BulkCodec); ok && t.size > 0 {
+		if first = t.full(row); first > n {
+			first = n
+		}
+		if first > 0 {
+			bc.DecodeTiles(src[:first*t.size], dst, x, y, first)
+		}
+	}
+	for i := first; i < n; i++ {
+		c.Decode(src[i*t.size:(i+1)*t.size], dst, x+i*t.tw, y)
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+package tileconv
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// TMS9918Palette is the 16-color palette of the TMS9918 video chip,
+// used by e.g. the MSX1, ColecoVision and SG-1000. Color 0 is
+// transparent.
+var TMS9918Palette = color.Palette{
+	color.RGBA{0x00, 0x00, 0x00, 0x00}, // transparent
+	color.RGBA{0x00, 0x00, 0x00, 0xFF}, // black
+	color.RGBA{0x21, 0xC8, 0x42, 0xFF}, // medium green
+	color.RGBA{0x5E, 0xDC, 0x78, 0xFF}, // light green
+	color.RGBA{0x54, 0x55, 0xED, 0xFF}, // dark blue
+	color.RGBA{0x7D, 0x76, 0xFC, 0xFF}, // light blue
+	color.RGBA{0xD4, 0x52, 0x4D, 0xFF}, // dark red
+	color.RGBA{0x42, 0xEB, 0xF5, 0xFF}, // cyan
+	color.RGBA{0xFC, 0x55, 0x54, 0xFF}, // medium red
+	color.RGBA{0xFF, 0x79, 0x78, 0xFF}, // light red
+	color.RGBA{0xD4, 0xC1, 0x54, 0xFF}, // dark yellow
+	color.RGBA{0xE6, 0xCE, 0x80, 0xFF}, // light yellow
+	color.RGBA{0x21, 0xB0, 0x3B, 0xFF}, // dark green
+	color.RGBA{0xC9, 0x5B, 0xBA, 0xFF}, // magenta
+	color.RGBA{0xCC, 0xCC, 0xCC, 0xFF}, // gray
+	color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, // white
+}
+
+// EncodeTMS9918 encodes an image into the pattern and color tables used
+// by the TMS9918 Graphics II mode.
+//
+// The tiles are read from the image in the same order as by Encode, and
+// each tile is encoded into 8 bytes of each table. The pattern table
+// holds the tile as a 1bpp image (like RowPlanar with BD1), while the
+// color table holds a byte for each row of the tile, with the color for
+// set pattern bits in the high nibble, and for clear bits in the low.
+//
+// The image must use TMS9918Palette color indexes (0-15), and each row
+// of each tile can use at most two colors. If any rows use more, a
+// *ColorClashError is returned that describes all of those rows.
+//
+// The lower of the two colors is used for the clear pattern bits, and
+// rows with a single color have all their pattern bits clear.
+func EncodeTMS9918(src image.PalettedImage) (patterns, colors []byte, err error) {
+	var clashes []ColorClash
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y += 8 {
+		for x := b.Min.X; x < b.Max.X; x += 8 {
+			for iy := 0; iy < 8; iy++ {
+				row := image.Rect(x, y+iy, x+8, y+iy+1)
+				used := cellColors(src, row, 1)
+				if max := used[len(used)-1]; max > 15 {
+					return nil, nil, fmt.Errorf(
+						"invalid TMS9918 color %v in row at %v",
+						max, row.Min,
+					)
+				}
+				if len(used) > 2 {
+					clashes = append(clashes, ColorClash{
+						Cell: row, Colors: used, Max: 2,
+					})
+					continue
+				}
+
+				bg, fg := used[0], used[len(used)-1]
+				var pattern byte
+				for ix := 0; ix < 8; ix++ {
+					pattern <<= 1
+					if src.ColorIndexAt(x+ix, y+iy) != bg {
+						pattern |= 1
+					}
+				}
+				patterns = append(patterns, pattern)
+				colors = append(colors, fg<<4|bg)
+			}
+		}
+	}
+
+	if len(clashes) > 0 {
+		return nil, nil, &ColorClashError{Clashes: clashes}
+	}
+
+	return patterns, colors, nil
+}
+
+// DecodeTMS9918 decodes TMS9918 Graphics II pattern and color tables
+// (as produced by EncodeTMS9918) into the given image, placing the
+// tiles in the same way as Decode. The two tables must have the same
+// size, and the image should have a palette with at least 16 colors.
+func DecodeTMS9918(patterns, colors []byte, dst *image.Paletted) error {
+	if len(patterns) != len(colors) {
+		return errors.New("TMS9918 pattern and color table sizes differ")
+	}
+
+	b := dst.Bounds()
+	i := 0
+	for y := b.Min.Y; y < b.Max.Y && i+8 <= len(patterns); y += 8 {
+		for x := b.Min.X; x < b.Max.X && i+8 <= len(patterns); x += 8 {
+			for iy := 0; iy < 8; iy++ {
+				pattern, fg, bg := patterns[i], colors[i]>>4, colors[i]&15
+				i++
+				for ix := 0; ix < 8; ix++ {
+					c := bg
+					if pattern&0x80 != 0 {
+						c = fg
+					}
+					pattern <<= 1
+					dst.SetColorIndex(x+ix, y+iy, c)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package tileconv_test
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestEncodeTMS9918(t *testing.T) {
+	src := image.NewPaletted(image.Rect(0, 0, 16, 8), tileconv.TMS9918Palette)
+	// First tile, row 0: color 4 on 1.
+	for x := 0; x < 8; x++ {
+		src.SetColorIndex(x, 0, 1)
+	}
+	src.SetColorIndex(0, 0, 4)
+	src.SetColorIndex(7, 0, 4)
+	// Second tile, row 3: only color 15.
+	for x := 8; x < 16; x++ {
+		src.SetColorIndex(x, 3, 15)
+	}
+	// Second tile, row 7: color 2 on 0.
+	src.SetColorIndex(9, 7, 2)
+
+	patterns, colors, err := tileconv.EncodeTMS9918(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verify(t, "bad patterns", patterns, []byte{
+		0b10000001, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0b01000000,
+	})
+	verify(t, "bad colors", colors, []byte{
+		0x41, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0xFF, 0x00, 0x00, 0x00, 0x20,
+	})
+
+	got := image.NewPaletted(src.Rect, tileconv.TMS9918Palette)
+	if err := tileconv.DecodeTMS9918(patterns, colors, got); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	verify(t, "bad decoded image", got, src)
+}
+
+func TestEncodeTMS9918_Clash(t *testing.T) {
+	src := image.NewPaletted(image.Rect(0, 0, 16, 16), tileconv.TMS9918Palette)
+	src.SetColorIndex(1, 2, 5)
+	src.SetColorIndex(2, 2, 6)
+	src.SetColorIndex(12, 9, 7)
+	src.SetColorIndex(13, 9, 8)
+	src.SetColorIndex(14, 9, 9)
+
+	_, _, err := tileconv.EncodeTMS9918(src)
+
+	var ce *tileconv.ColorClashError
+	if !errors.As(err, &ce) {
+		t.Fatalf("wrong error: want *ColorClashError, got %#v", err)
+	}
+	verify(t, "bad clashes", ce.Clashes, []tileconv.ColorClash{
+		{Cell: image.Rect(0, 2, 8, 3), Colors: []uint8{0, 5, 6}, Max: 2},
+		{Cell: image.Rect(8, 9, 16, 10), Colors: []uint8{0, 7, 8, 9}, Max: 2},
+	})
+
+	src.SetColorIndex(0, 0, 16)
+	if _, _, err := tileconv.EncodeTMS9918(src); err == nil {
+		t.Errorf("missing error for invalid color")
+	}
+
+	dst := image.NewPaletted(src.Rect, tileconv.TMS9918Palette)
+	if err := tileconv.DecodeTMS9918(make([]byte, 8), nil, dst); err == nil {
+		t.Errorf("missing error for mismatched table sizes")
+	}
+}
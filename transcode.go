@@ -0,0 +1,111 @@
+package tileconv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+)
+
+// LostColors describes a tile that uses colors that the target format
+// of a conversion can not store, e.g. because its bit depth is smaller.
+type LostColors struct {
+	// Tile is the index of the tile in the source data.
+	Tile int
+
+	// Colors holds the color indexes that were lost, in ascending order.
+	Colors []uint8
+}
+
+// String returns a description of the lost colors.
+func (l LostColors) String() string {
+	return fmt.Sprintf("tile %v uses colors %v", l.Tile, l.Colors)
+}
+
+// LostColorsError is the error returned when tile data can not be
+// converted because some of its tiles use colors that would be lost.
+type LostColorsError struct {
+	Tiles []LostColors
+}
+
+// Error implements error.
+func (e *LostColorsError) Error() string {
+	if len(e.Tiles) == 1 {
+		return "lost colors: " + e.Tiles[0].String()
+	}
+	return fmt.Sprintf(
+		"lost colors in %v tiles; first %v", len(e.Tiles), e.Tiles[0],
+	)
+}
+
+// Transcode converts tile data directly from one format to another, by
+// decoding each tile with one codec and encoding it with the other, in
+// the same order.
+//
+// The codecs must have the same tile size, and the source data must be
+// a whole number of tiles.
+//
+// If the target codec can not store all the colors that are used in the
+// source data, e.g. because its bit depth is smaller, the converted data
+// is returned along with a *LostColorsError that lists the tiles and
+// colors that were lost.
+func Transcode(src []byte, from, to Codec) ([]byte, error) {
+	fw, fh := TileSize(from)
+	tw, th := TileSize(to)
+	if fw != tw || fh != th {
+		return nil, fmt.Errorf(
+			"tile sizes differ: %vx%v and %vx%v", fw, fh, tw, th,
+		)
+	}
+	if from.Size() <= 0 || to.Size() <= 0 {
+		return nil, errors.New("codec has no tile data")
+	}
+	if len(src)%from.Size() != 0 {
+		return nil, errors.New("source is not a whole number of tiles")
+	}
+	tiles := len(src) / from.Size()
+
+	// The tiles are decoded into a single column, so that Encode reads
+	// them back out in the same order.
+	img := image.NewPaletted(image.Rect(0, 0, fw, fh*tiles), nil)
+	Decode(src, img, from)
+
+	var buf bytes.Buffer
+	buf.Grow(tiles * to.Size())
+	if err := Encode(img, &buf, to); err != nil {
+		return nil, err
+	}
+	dst := buf.Bytes()
+
+	// Check that the colors survived, by decoding the result.
+	check := image.NewPaletted(img.Rect, nil)
+	Decode(dst, check, to)
+
+	var lost []LostColors
+	tileLen := fw * fh
+	for t := 0; t < tiles; t++ {
+		var bad [256]bool
+		found := false
+		for i := t * tileLen; i < (t+1)*tileLen; i++ {
+			if img.Pix[i] != check.Pix[i] {
+				bad[img.Pix[i]] = true
+				found = true
+			}
+		}
+		if !found {
+			continue
+		}
+		l := LostColors{Tile: t}
+		for c, b := range bad {
+			if b {
+				l.Colors = append(l.Colors, uint8(c))
+			}
+		}
+		lost = append(lost, l)
+	}
+	if len(lost) > 0 {
+		return dst, &LostColorsError{Tiles: lost}
+	}
+
+	return dst, nil
+}
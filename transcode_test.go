@@ -0,0 +1,69 @@
+package tileconv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestTranscode(t *testing.T) {
+	src := newRandomSheet(32, 16, 1)
+	for bd := tileconv.BD1; bd <= tileconv.BD8; bd++ {
+		masked := mapPixels(src, bd.Colors(), func(c uint8) uint8 {
+			return c & bd.ColorMask()
+		})
+		from := tileconv.RowPlanar{BitDepth: bd}
+		to := tileconv.TilePlanar{BitDepth: bd}
+
+		got, err := tileconv.Transcode(encodeAll(t, masked, from), from, to)
+		if err != nil {
+			t.Fatalf("BD%v: %v", bd, err)
+		}
+		verify(t, "bad transcoded data", got, encodeAll(t, masked, to))
+	}
+}
+
+func TestTranscode_LostColors(t *testing.T) {
+	// Tile 0 uses colors 0-3, tile 1 uses 0-15, tile 2 uses 2 and 5.
+	src := newRandomSheet(24, 8, 2)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 24; x++ {
+			c := uint8(y*8+x) & 15
+			switch x / 8 {
+			case 0:
+				c &= 3
+			case 2:
+				c = []uint8{2, 5}[x&1]
+			}
+			src.SetColorIndex(x, y, c)
+		}
+	}
+	from := tileconv.Packed{BitDepth: tileconv.BD4}
+	to := tileconv.RowPlanar{BitDepth: tileconv.BD2}
+
+	got, err := tileconv.Transcode(encodeAll(t, src, from), from, to)
+	var le *tileconv.LostColorsError
+	if !errors.As(err, &le) {
+		t.Fatalf("bad error: want LostColorsError, got %v", err)
+	}
+	want := []tileconv.LostColors{
+		{Tile: 1, Colors: []uint8{4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}},
+		{Tile: 2, Colors: []uint8{5}},
+	}
+	verify(t, "bad lost colors", le.Tiles, want)
+	if len(got) != 3*to.Size() {
+		t.Errorf("bad data size: want %v, got %v", 3*to.Size(), len(got))
+	}
+}
+
+func TestTranscode_Errors(t *testing.T) {
+	rp := tileconv.RowPlanar{BitDepth: tileconv.BD2}
+	if _, err := tileconv.Transcode(make([]byte, 15), rp, rp); err == nil {
+		t.Error("no error for a partial tile")
+	}
+	wp := tileconv.WordPlanar{BitDepth: tileconv.BD2}
+	if _, err := tileconv.Transcode(make([]byte, 16), rp, wp); err == nil {
+		t.Error("no error for different tile sizes")
+	}
+}
@@ -0,0 +1,72 @@
+package tileconv
+
+// WordPlanar is a Codec that encodes each tile as a word-interleaved
+// planar image, as used by the Atari ST. Each row of 16 pixels is
+// stored as one big-endian 16-bit word per plane, with the words for
+// all the planes of a row stored before the next row.
+//
+// Tiles are 16 pixels wide, and Height pixels tall (16 if zero), which
+// fits 16x16 sprites. With a Height of 1, encoding a whole image gives
+// the layout of the Atari ST screen memory.
+type WordPlanar struct {
+	BitDepth BitDepth
+	Height   int
+}
+
+var _ Codec = WordPlanar{}
+var _ TileSizer = WordPlanar{}
+
+func (c WordPlanar) height() int {
+	if c.Height > 0 {
+		return c.Height
+	}
+	return 16
+}
+
+// Size implements Codec, returning the size of a tile.
+func (c WordPlanar) Size() int {
+	return c.height() * c.BitDepth.Planes() * 2
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c WordPlanar) TileSize() (w, h int) {
+	return 16, c.height()
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c WordPlanar) Encode(src SourceImage, x, y int, dst []byte) {
+	planes := c.BitDepth.Planes()
+	for iy := 0; iy < c.height(); iy++ {
+		var words [8]uint16
+		for ix := 0; ix < 16; ix++ {
+			color := src.ColorIndexAt(x+ix, y+iy)
+			for p := 0; p < planes; p++ {
+				words[p] = (words[p] << 1) | uint16(color&1)
+				color >>= 1
+			}
+		}
+		for p := 0; p < planes; p++ {
+			i := (iy*planes + p) * 2
+			dst[i], dst[i+1] = byte(words[p]>>8), byte(words[p])
+		}
+	}
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c WordPlanar) Decode(src []byte, dst DestImage, x, y int) {
+	planes := c.BitDepth.Planes()
+	for iy := 0; iy < c.height(); iy++ {
+		row := [16]uint8{}
+		for p := 0; p < planes; p++ {
+			i := (iy*planes + p) * 2
+			d := uint16(src[i])<<8 | uint16(src[i+1])
+			for ix := 16 - 1; ix >= 0; ix-- {
+				row[ix] |= uint8(d&1) << p
+				d >>= 1
+			}
+		}
+		for ix := 0; ix < 16; ix++ {
+			dst.SetColorIndex(x+ix, y+iy, row[ix])
+		}
+	}
+}
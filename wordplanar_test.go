@@ -0,0 +1,75 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+func TestWordPlanarSize(t *testing.T) {
+	check := func(c tileconv.WordPlanar, wantH, want int) {
+		t.Helper()
+		w, h := tileconv.TileSize(c)
+		verify(t, "bad tile size", [2]int{w, h}, [2]int{16, wantH})
+		verify(t, "bad size", c.Size(), want)
+	}
+	check(tileconv.WordPlanar{BitDepth: tileconv.BD1}, 16, 32)
+	check(tileconv.WordPlanar{BitDepth: tileconv.BD4}, 16, 128)
+	check(tileconv.WordPlanar{BitDepth: tileconv.BD2, Height: 1}, 1, 4)
+	check(tileconv.WordPlanar{BitDepth: tileconv.BD4, Height: 8}, 8, 64)
+}
+
+func TestWordPlanarEncode(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 16, 2), newTestPalette())
+	copy(img.Pix, []uint8{
+		0x0F, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0xF0,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+	})
+
+	c := tileconv.WordPlanar{BitDepth: tileconv.BD4, Height: 2}
+	got := make([]byte, c.Size()+2)
+	got[len(got)-1], got[len(got)-2] = 0xAA, 0x55
+	c.Encode(img, 0, 0, got)
+
+	verify(t, "bad encoded data", got, []byte{
+		0b11010101, 0b01010100, // row 0, plane 0
+		0b10110011, 0b00110010, // row 0, plane 1
+		0b10001111, 0b00001110, // row 0, plane 2
+		0b10000000, 0b11111110, // row 0, plane 3
+		0b10000000, 0b00000000, // row 1, plane 0
+		0b00000000, 0b00000001, // row 1, plane 1
+		0b00000000, 0b00000000, // row 1, plane 2
+		0b00000000, 0b00000000, // row 1, plane 3
+		0x55, 0xAA,
+	})
+
+	dec := image.NewPaletted(img.Rect, img.Palette)
+	c.Decode(got, dec, 0, 0)
+	for i := range img.Pix {
+		img.Pix[i] &= 0x0F
+	}
+	verify(t, "bad decoded pixels", dec.Pix, img.Pix)
+}
+
+func TestWordPlanarScreen(t *testing.T) {
+	// With a height of 1, a whole image is encoded row by row, with the
+	// 16-pixel groups of each row following each other.
+	img := image.NewPaletted(image.Rect(0, 0, 32, 2), newTestPalette())
+	img.SetColorIndex(16, 0, 1)
+	img.SetColorIndex(0, 1, 2)
+
+	var buf bytes.Buffer
+	c := tileconv.WordPlanar{BitDepth: tileconv.BD2, Height: 1}
+	if err := tileconv.Encode(img, &buf, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verify(t, "bad screen data", buf.Bytes(), []byte{
+		0x00, 0x00, 0x00, 0x00, 0x80, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	})
+}
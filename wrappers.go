@@ -0,0 +1,227 @@
+package tileconv
+
+import (
+	"math/bits"
+)
+
+// These codecs wrap another codec, transforming its encoded data or its
+// color indexes in some way. They can be stacked to describe formats
+// that are variations of an existing codec, e.g.:
+//
+//	XOR{Key: key, Codec: ByteSwap{Codec: RowPlanar{BitDepth: BD4}}}
+
+// ByteSwap is a Codec that wraps another codec, reversing the order of
+// the bytes in each word of its encoded data, e.g. to switch between
+// big-endian and little-endian 16-bit words.
+type ByteSwap struct {
+	Codec Codec
+
+	// WordSize is the number of bytes in each word (default 2). It must
+	// divide the size of the wrapped codec's tiles.
+	WordSize int
+}
+
+var _ Codec = ByteSwap{}
+var _ TileSizer = ByteSwap{}
+
+func (c ByteSwap) wordSize() int {
+	if c.WordSize > 0 {
+		return c.WordSize
+	}
+	return 2
+}
+
+func (c ByteSwap) swap(data []byte) {
+	ws := c.wordSize()
+	for i := 0; i+ws <= len(data); i += ws {
+		w := data[i : i+ws]
+		for a, b := 0, ws-1; a < b; a, b = a+1, b-1 {
+			w[a], w[b] = w[b], w[a]
+		}
+	}
+}
+
+// Size implements Codec, returning the size of a tile.
+func (c ByteSwap) Size() int {
+	return c.Codec.Size()
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c ByteSwap) TileSize() (w, h int) {
+	return TileSize(c.Codec)
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c ByteSwap) Encode(src SourceImage, x, y int, dst []byte) {
+	c.Codec.Encode(src, x, y, dst)
+	c.swap(dst[:c.Size()])
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c ByteSwap) Decode(src []byte, dst DestImage, x, y int) {
+	data := append([]byte(nil), src[:c.Size()]...)
+	c.swap(data)
+	c.Codec.Decode(data, dst, x, y)
+}
+
+// ReverseBits is a Codec that wraps another codec, reversing the order
+// of the bits in each byte of its encoded data.
+type ReverseBits struct {
+	Codec Codec
+}
+
+var _ Codec = ReverseBits{}
+var _ TileSizer = ReverseBits{}
+
+// Size implements Codec, returning the size of a tile.
+func (c ReverseBits) Size() int {
+	return c.Codec.Size()
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c ReverseBits) TileSize() (w, h int) {
+	return TileSize(c.Codec)
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c ReverseBits) Encode(src SourceImage, x, y int, dst []byte) {
+	c.Codec.Encode(src, x, y, dst)
+	for i, b := range dst[:c.Size()] {
+		dst[i] = bits.Reverse8(b)
+	}
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c ReverseBits) Decode(src []byte, dst DestImage, x, y int) {
+	data := make([]byte, c.Size())
+	for i := range data {
+		data[i] = bits.Reverse8(src[i])
+	}
+	c.Codec.Decode(data, dst, x, y)
+}
+
+// XOR is a Codec that wraps another codec, XORing its encoded data with
+// a key, as is sometimes done to obfuscate graphics data.
+//
+// The key is repeated as needed to cover the data of a tile, starting
+// over from the beginning of the key for each tile. An empty key leaves
+// the data unchanged.
+type XOR struct {
+	Codec Codec
+	Key   []byte
+}
+
+var _ Codec = XOR{}
+var _ TileSizer = XOR{}
+
+func (c XOR) apply(dst, src []byte) {
+	if len(c.Key) == 0 {
+		copy(dst, src)
+		return
+	}
+	for i := range dst {
+		dst[i] = src[i] ^ c.Key[i%len(c.Key)]
+	}
+}
+
+// Size implements Codec, returning the size of a tile.
+func (c XOR) Size() int {
+	return c.Codec.Size()
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c XOR) TileSize() (w, h int) {
+	return TileSize(c.Codec)
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c XOR) Encode(src SourceImage, x, y int, dst []byte) {
+	c.Codec.Encode(src, x, y, dst)
+	data := dst[:c.Size()]
+	c.apply(data, data)
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c XOR) Decode(src []byte, dst DestImage, x, y int) {
+	data := make([]byte, c.Size())
+	c.apply(data, src)
+	c.Codec.Decode(data, dst, x, y)
+}
+
+// PlaneOrder is a Codec that wraps another codec, reordering the bit
+// planes that it stores, by moving the bits of each color index around
+// before encoding it, and back after decoding it.
+//
+// Planes gives, for each plane of the wrapped codec, which bit of the
+// color index is stored in it, so e.g. {1, 0} swaps the two planes of a
+// 2bpp codec. Bits of the color index that are not listed are lost when
+// encoding, and decoded as zero.
+type PlaneOrder struct {
+	Codec  Codec
+	Planes []int
+}
+
+var _ Codec = PlaneOrder{}
+var _ TileSizer = PlaneOrder{}
+
+// toStored returns the color index that the wrapped codec stores for
+// the given color index.
+func (c PlaneOrder) toStored(color uint8) uint8 {
+	var stored uint8
+	for plane, bit := range c.Planes {
+		stored |= (color >> bit & 1) << plane
+	}
+	return stored
+}
+
+// fromStored returns the color index for the given color index that
+// was decoded by the wrapped codec.
+func (c PlaneOrder) fromStored(stored uint8) uint8 {
+	var color uint8
+	for plane, bit := range c.Planes {
+		color |= (stored >> plane & 1) << bit
+	}
+	return color
+}
+
+// Size implements Codec, returning the size of a tile.
+func (c PlaneOrder) Size() int {
+	return c.Codec.Size()
+}
+
+// TileSize implements TileSizer, returning the size of a tile.
+func (c PlaneOrder) TileSize() (w, h int) {
+	return TileSize(c.Codec)
+}
+
+// Encode implements Codec, encoding a tile image into bytes.
+func (c PlaneOrder) Encode(src SourceImage, x, y int, dst []byte) {
+	c.Codec.Encode(planeOrderSource{src, c}, x, y, dst)
+}
+
+// Decode implements Codec, decoding bytes into an image.
+func (c PlaneOrder) Decode(src []byte, dst DestImage, x, y int) {
+	c.Codec.Decode(src, planeOrderDest{dst, c}, x, y)
+}
+
+// planeOrderSource is the image that PlaneOrder gives its wrapped codec
+// when encoding.
+type planeOrderSource struct {
+	src SourceImage
+	c   PlaneOrder
+}
+
+func (s planeOrderSource) ColorIndexAt(x, y int) uint8 {
+	return s.c.toStored(s.src.ColorIndexAt(x, y))
+}
+
+// planeOrderDest is the image that PlaneOrder gives its wrapped codec
+// when decoding.
+type planeOrderDest struct {
+	dst DestImage
+	c   PlaneOrder
+}
+
+func (d planeOrderDest) SetColorIndex(x, y int, idx uint8) {
+	d.dst.SetColorIndex(x, y, d.c.fromStored(idx))
+}
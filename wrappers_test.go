@@ -0,0 +1,162 @@
+package tileconv_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/edorfaus/tileconv"
+)
+
+// encodeTile encodes the tile at 0,0 of src with the codec.
+func encodeTile(c tileconv.Codec, src tileconv.SourceImage) []byte {
+	data := make([]byte, c.Size())
+	c.Encode(src, 0, 0, data)
+	return data
+}
+
+// checkWrapperRoundTrip checks that the codec decodes its own encoded
+// data back into the source tile, without modifying the data.
+func checkWrapperRoundTrip(
+	t *testing.T, name string, c tileconv.Codec, src *image.Paletted,
+	mask uint8,
+) {
+	t.Helper()
+	data := encodeTile(c, src)
+	orig := append([]byte(nil), data...)
+
+	got := image.NewPaletted(src.Rect, src.Palette)
+	c.Decode(data, got, 0, 0)
+	verify(t, name+": corrupted source data", data, orig)
+
+	want := image.NewPaletted(src.Rect, src.Palette)
+	for i, p := range src.Pix {
+		want.Pix[i] = p & mask
+	}
+	verify(t, name+": bad decoded pixels", got.Pix, want.Pix)
+}
+
+func TestByteSwap(t *testing.T) {
+	src := newRandomSheet(8, 8, 1)
+	for bd := tileconv.BD1; bd <= tileconv.BD8; bd++ {
+		c := tileconv.ByteSwap{Codec: tileconv.PackedWord{BitDepth: bd}}
+		want := encodeTile(
+			tileconv.PackedWord{BitDepth: bd, BigEndian: true}, src,
+		)
+		if got := encodeTile(c, src); !bytes.Equal(got, want) {
+			t.Errorf("BD%v: bad encoded data:\nwant: %v\n got: %v",
+				bd, want, got)
+		}
+		checkWrapperRoundTrip(t, "ByteSwap", c, src, bd.ColorMask())
+	}
+
+	c := tileconv.ByteSwap{
+		Codec:    tileconv.Packed{BitDepth: tileconv.BD8},
+		WordSize: 4,
+	}
+	got := encodeTile(c, src)
+	verify(t, "bad swapped word", got[:4], []byte{
+		src.Pix[3], src.Pix[2], src.Pix[1], src.Pix[0],
+	})
+}
+
+func TestReverseBits(t *testing.T) {
+	src := newRandomSheet(8, 8, 1)
+	mirrored := image.NewPaletted(src.Rect, src.Palette)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			mirrored.SetColorIndex(7-x, y, src.ColorIndexAt(x, y))
+		}
+	}
+
+	for bd := tileconv.BD1; bd <= tileconv.BD8; bd++ {
+		c := tileconv.ReverseBits{Codec: tileconv.RowPlanar{BitDepth: bd}}
+		want := encodeTile(tileconv.RowPlanar{BitDepth: bd}, mirrored)
+		if got := encodeTile(c, src); !bytes.Equal(got, want) {
+			t.Errorf("BD%v: bad encoded data:\nwant: %v\n got: %v",
+				bd, want, got)
+		}
+		checkWrapperRoundTrip(t, "ReverseBits", c, src, bd.ColorMask())
+	}
+}
+
+func TestXOR(t *testing.T) {
+	src := newRandomSheet(8, 8, 1)
+	inner := tileconv.TilePlanar{BitDepth: tileconv.BD2}
+	plain := encodeTile(inner, src)
+
+	c := tileconv.XOR{Codec: inner, Key: []byte{0xFF, 0x5A, 0x00}}
+	got := encodeTile(c, src)
+	for i := range plain {
+		if want := plain[i] ^ c.Key[i%3]; got[i] != want {
+			t.Errorf("bad encoded byte %v: want %v, got %v", i, want, got[i])
+		}
+	}
+	checkWrapperRoundTrip(t, "XOR", c, src, 3)
+
+	c.Key = nil
+	verify(t, "bad encoded data with no key", encodeTile(c, src), plain)
+	checkWrapperRoundTrip(t, "XOR without key", c, src, 3)
+}
+
+func TestPlaneOrder(t *testing.T) {
+	src := newRandomSheet(8, 8, 1)
+	inner := tileconv.RowPlanar{BitDepth: tileconv.BD2}
+	plain := encodeTile(inner, src)
+
+	c := tileconv.PlaneOrder{Codec: inner, Planes: []int{1, 0}}
+	got := encodeTile(c, src)
+	for i := 0; i < len(plain); i += 2 {
+		verify(t, "bad swapped planes", got[i:i+2], []byte{
+			plain[i+1], plain[i],
+		})
+	}
+	checkWrapperRoundTrip(t, "PlaneOrder", c, src, 3)
+
+	// Planes can be left out, and bits can be moved to other planes.
+	c = tileconv.PlaneOrder{
+		Codec: tileconv.Packed{BitDepth: tileconv.BD4}, Planes: []int{
+			7, 0, 0, 5,
+		},
+	}
+	img := image.NewPaletted(image.Rect(0, 0, 8, 8), newTestPalette())
+	img.Pix[0] = 0b1010_0001
+	data := encodeTile(c, img)
+	verify(t, "bad moved bits", data[0], byte(0b1111_0000))
+
+	dec := image.NewPaletted(img.Rect, img.Palette)
+	c.Decode(data, dec, 0, 0)
+	verify(t, "bad decoded moved bits", dec.Pix[0], uint8(0b1010_0001))
+}
+
+func TestWrapperStack(t *testing.T) {
+	src := newRandomSheet(40, 32, 1)
+	c := tileconv.XOR{
+		Key: []byte{0x12, 0x34},
+		Codec: tileconv.ByteSwap{
+			Codec: tileconv.PlaneOrder{
+				Codec:  tileconv.WordPlanar{BitDepth: tileconv.BD4},
+				Planes: []int{3, 2, 1, 0},
+			},
+		},
+	}
+	w, h := tileconv.TileSize(c)
+	verify(t, "bad tile size", [2]int{w, h}, [2]int{16, 16})
+
+	var buf bytes.Buffer
+	if err := tileconv.Encode(src, &buf, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verify(t, "bad data length", buf.Len(), 3*2*c.Size())
+
+	got := image.NewPaletted(image.Rect(0, 0, 48, 32), src.Palette)
+	tileconv.Decode(buf.Bytes(), got, c)
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 48; x++ {
+			want := src.ColorIndexAt(x, y) & 0x0F
+			if g := got.ColorIndexAt(x, y); g != want {
+				t.Fatalf("bad pixel at %v,%v: want %v, got %v", x, y, want, g)
+			}
+		}
+	}
+}